@@ -10,7 +10,8 @@ import (
 )
 
 // 构建更新语句
-func (cli *Cli) buildUpdateQuery(tb string, updateMap map[string]any, fields []string) (string, []any, error) {
+// order 字段顺序 (一般为结构体字段的声明顺序), 不在其中的字段按字典序排在后面
+func (cli *Cli) buildUpdateQuery(tb string, updateMap map[string]any, order []string, fields []string) (string, []any, error) {
 	if len(updateMap) == 0 {
 		return "", nil, errors.New("updateMap is empty")
 	}
@@ -19,8 +20,15 @@ func (cli *Cli) buildUpdateQuery(tb string, updateMap map[string]any, fields []s
 	args := make([]any, 0, len(updateMap)+len(fields))
 
 	var fieldStr string
-	for tag, val := range updateMap {
-		if !utils.InSlice(tag, fields) {
+	for _, tag := range utils.OrderedKeys(updateMap, order) {
+		if utils.InSlice(tag, fields) {
+			continue
+		}
+		val := updateMap[tag]
+		if expr, ok := val.(Expression); ok {
+			args = append(args, expr.args...)
+			fieldStr = utils.Concat(fieldStr, "`", tag, "` = ", expr.sql, ",")
+		} else {
 			args = append(args, val)
 			fieldStr = utils.Concat(fieldStr, "`", tag, "` = ?,")
 		}
@@ -36,6 +44,9 @@ func (cli *Cli) buildUpdateQuery(tb string, updateMap map[string]any, fields []s
 		}
 	}
 
+	if fieldStr == "" {
+		return "", nil, errors.New("no fields to update")
+	}
 	fieldStr = fieldStr[:len(fieldStr)-1]
 
 	query = utils.Concat(query, fieldStr, " ", where)
@@ -44,7 +55,8 @@ func (cli *Cli) buildUpdateQuery(tb string, updateMap map[string]any, fields []s
 }
 
 // 构建批量更新语句
-func (cli *Cli) buildUpdateBatchQuery(tb string, mapSlice []map[string]any, fields ...string) (string, []any, error) {
+// order 字段顺序 (一般为结构体字段的声明顺序), 不在其中的字段按字典序排在后面
+func (cli *Cli) buildUpdateBatchQuery(tb string, mapSlice []map[string]any, order []string, fields ...string) (string, []any, error) {
 
 	if len(mapSlice) == 0 {
 		return "", nil, errors.New("updateMaps is empty")
@@ -62,7 +74,10 @@ func (cli *Cli) buildUpdateBatchQuery(tb string, mapSlice []map[string]any, fiel
 		}
 	}
 
-	updates, args := cli.updateCaseWhenThen(mapSlice, fields...)
+	updates, args := cli.updateCaseWhenThen(mapSlice, order, fields...)
+	if updates == "" {
+		return "", nil, errors.New("no fields to update")
+	}
 
 	fieldArgs := make([]any, 0, len(mapSlice))
 	for _, datum := range mapSlice {
@@ -85,30 +100,53 @@ func (cli *Cli) buildUpdateBatchQuery(tb string, mapSlice []map[string]any, fiel
 	return query, args, nil
 }
 
-func (cli *Cli) updateCaseWhenThen(updateData []map[string]any, fields ...string) (string, []any) {
+func (cli *Cli) updateCaseWhenThen(updateData []map[string]any, order []string, fields ...string) (string, []any) {
 
 	var fs = make([]string, 0, len(fields))
 	for _, field := range fields {
 		fs = append(fs, utils.Concat(" `", field, "` = ? "))
 	}
 
-	subCase := utils.Concat("when", strings.Join(fs, "And"), "then ? ")
+	subCase := utils.Concat("when", strings.Join(fs, "And"), "then ")
 
 	args := make([]any, 0, len(updateData[0])*len(updateData))
 
-	var updateClauses []string
-	for field := range updateData[0] {
-		if utils.InSlice(field, fields) {
-			continue
+	// 各行更新的字段可能不同 (如 NonZeroOnly), 取所有行字段的并集
+	union := make(map[string]bool)
+	for _, updateMap := range updateData {
+		for field := range updateMap {
+			if !utils.InSlice(field, fields) {
+				union[field] = true
+			}
 		}
+	}
+	updateFields := utils.OrderedKeys(union, order)
+
+	var updateClauses []string
+	for _, field := range updateFields {
+		var partial bool
 		var subCases = make([]string, 0, len(updateData))
 		for _, updateMap := range updateData {
-			subCases = append(subCases, subCase)
+			if _, ok := updateMap[field]; !ok {
+				partial = true
+				continue
+			}
 
 			for _, key := range fields {
 				args = append(args, updateMap[key])
 			}
-			args = append(args, updateMap[field])
+
+			if expr, ok := updateMap[field].(Expression); ok {
+				subCases = append(subCases, utils.Concat(subCase, expr.sql, " "))
+				args = append(args, expr.args...)
+			} else {
+				subCases = append(subCases, utils.Concat(subCase, "? "))
+				args = append(args, updateMap[field])
+			}
+		}
+		// 未更新该字段的行保持原值
+		if partial {
+			subCases = append(subCases, utils.Concat("else `", field, "`"))
 		}
 		updateClauses = append(updateClauses, fmt.Sprintf("%s = \n\tCASE \n\t\t%s \n\tEND", field, strings.Join(subCases, "\n\t\t")))
 	}
@@ -116,11 +154,54 @@ func (cli *Cli) updateCaseWhenThen(updateData []map[string]any, fields ...string
 	return strings.Join(updateClauses, ",\n"), args
 }
 
+// 构建原子增减语句 如: UPDATE tb SET `gold` = `gold` + ? WHERE ...
+func (cli *Cli) buildIncrQuery(tb string, fields []string, op string, where string) (string, error) {
+	if len(fields) == 0 {
+		return "", errors.New("incr fields is empty")
+	}
+
+	sets := make([]string, 0, len(fields))
+	for _, field := range fields {
+		sets = append(sets, utils.Concat("`", field, "` = `", field, "` ", op, " ?"))
+	}
+
+	return utils.Concat("UPDATE ", tb, " SET ", strings.Join(sets, ","), " ", where), nil
+}
+
 // 构建统计计数语句
 func (cli *Cli) buildCountQuery(tb string, where string) string {
 	return utils.Concat("SELECT COUNT(1)", " FROM ", tb, " ", where)
 }
 
+// 构建聚合语句
+func (cli *Cli) buildAggregateQuery(fn string, tb string, field string, where string) string {
+	column := utils.Concat(fn, "(`", field, "`)")
+	if fn == "SUM" {
+		column = utils.Concat("COALESCE(", column, ", 0)")
+	}
+	return utils.Concat("SELECT ", column, " FROM ", tb, " ", where)
+}
+
+// 构建分组查询语句
+func (cli *Cli) buildGroupQuery(tb string, groupBy []string, aggregates []string, where string) (string, error) {
+	if len(groupBy) == 0 {
+		return "", errors.New("group fields is empty")
+	}
+	if len(aggregates) == 0 {
+		return "", errors.New("aggregates is empty")
+	}
+
+	groups := make([]string, 0, len(groupBy))
+	for _, field := range groupBy {
+		groups = append(groups, utils.Concat("`", field, "`"))
+	}
+	group := strings.Join(groups, ",")
+
+	query := utils.Concat("SELECT ", group, ",", strings.Join(aggregates, ","), " FROM ", tb, " ", where,
+		" GROUP BY ", group)
+	return query, nil
+}
+
 // 构建查询语句
 func (cli *Cli) buildSearchQuery(tb string, tags []string, where string) (string, error) {
 	if len(tags) == 0 {
@@ -138,10 +219,40 @@ func (cli *Cli) buildSearchQuery(tb string, tags []string, where string) (string
 	return query, nil
 }
 
+// 构建联表查询语句
+func (cli *Cli) buildJoinQuery(tables []joinTable, joins []joinClause, where string) (string, error) {
+	if len(tables) == 0 || len(tables) != len(joins)+1 {
+		return "", errors.New("join tables mismatch")
+	}
+
+	columns := make([]string, 0, len(tables)*len(tables[0].tags))
+	for _, table := range tables {
+		for _, tag := range table.tags {
+			columns = append(columns, joinColumn(table.alias, tag))
+		}
+	}
+	if len(columns) == 0 {
+		return "", errors.New("tags is empty")
+	}
+
+	query := utils.Concat("SELECT ", strings.Join(columns, ","), " FROM ", tables[0].tb, " AS `", tables[0].alias, "`")
+	for i, join := range joins {
+		query = utils.Concat(query, " ", join.kind, " ", tables[i+1].tb, " AS `", join.alias, "` ON ", join.on)
+	}
+
+	query = utils.Concat(query, " ", where)
+	return query, nil
+}
+
 // 构建插入语句
 func (cli *Cli) buildInsetQuery(tb string, tags []string) string {
+	return cli.buildInsertVerbQuery(insertVerb, tb, tags)
+}
+
+// 构建指定写入方式的插入语句 INSERT INTO / INSERT IGNORE INTO / REPLACE INTO
+func (cli *Cli) buildInsertVerbQuery(verb string, tb string, tags []string) string {
 
-	query := "INSERT INTO"
+	query := verb
 	query = utils.Concat(query, " ", tb, " (")
 	var fieldStr string
 	var nameStr string
@@ -157,17 +268,91 @@ func (cli *Cli) buildInsetQuery(tb string, tags []string) string {
 	return query
 }
 
+// 构建使用 ? 占位符的多行插入语句 如: INSERT INTO tb (`a`,`b`) VALUES (?,?),(?,?)
+func (cli *Cli) buildInsertValuesQuery(verb string, tb string, tags []string, rows int) (string, error) {
+	if len(tags) == 0 {
+		return "", errors.New("tags is empty")
+	}
+	if rows < 1 {
+		return "", errors.New("rows is empty")
+	}
+
+	fields := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		fields = append(fields, utils.Concat("`", tag, "`"))
+	}
+	values := utils.Concat("(", strings.Repeat("?,", len(tags)-1), "?)")
+
+	var builder strings.Builder
+	builder.WriteString(utils.Concat(verb, " ", tb, " (", strings.Join(fields, ","), ") VALUES "))
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(values)
+	}
+	return builder.String(), nil
+}
+
+// 构建 INSERT INTO ... SELECT 语句
+func (cli *Cli) buildInsertSelectQuery(tb string, cols []string, srcQuery string) string {
+	query := utils.Concat(insertVerb, " ", tb, " ")
+	if len(cols) > 0 {
+		fields := make([]string, 0, len(cols))
+		for _, col := range cols {
+			fields = append(fields, utils.Concat("`", col, "`"))
+		}
+		query = utils.Concat(query, "(", strings.Join(fields, ","), ") ")
+	}
+	return utils.Concat(query, srcQuery)
+}
+
 // 构建插入或更新语句
-func (cli *Cli) buildUpsertQuery(tb string, tags []string) string {
+func (cli *Cli) buildUpsertQuery(tb string, tags []string, opts *upsertOptions) string {
 	insetQuery := cli.buildInsetQuery(tb, tags)
 
-	updateTail := ""
-	for _, tag := range tags {
-		updateTail = utils.Concat(updateTail, "`", tag, "` = VALUES(`", tag, "`),")
+	// 插入的新值 VALUES(`tag`) 或 `alias`.`tag`
+	newValue := func(tag string) string {
+		if opts.rowAlias != "" {
+			return utils.Concat("`", opts.rowAlias, "`.`", tag, "`")
+		}
+		return utils.Concat("VALUES(`", tag, "`)")
+	}
+
+	updateCols := tags
+	if len(opts.updateCols) > 0 {
+		updateCols = opts.updateCols
+	}
+
+	exprCols := make([]string, 0, len(opts.exprs))
+	for _, expr := range opts.exprs {
+		exprCols = append(exprCols, expr.col)
+	}
+
+	updates := make([]string, 0, len(updateCols)+len(opts.exprs))
+	for _, tag := range updateCols {
+		if utils.InSlice(tag, opts.excludeCols) || utils.InSlice(tag, exprCols) {
+			continue
+		}
+		updates = append(updates, utils.Concat("`", tag, "` = ", newValue(tag)))
+	}
+	for _, expr := range opts.exprs {
+		if expr.expr == "" {
+			expr.expr = utils.Concat("`", expr.col, "` + ", newValue(expr.col))
+		}
+		updates = append(updates, utils.Concat("`", expr.col, "` = ", expr.expr))
+	}
+
+	// 没有需要更新的字段时 保持原值
+	if len(updates) == 0 {
+		updates = append(updates, utils.Concat("`", tags[0], "` = `", tags[0], "`"))
+	}
+
+	if opts.rowAlias != "" {
+		insetQuery = utils.Concat(insetQuery, " AS `", opts.rowAlias, "`")
 	}
-	updateTail = updateTail[:len(updateTail)-1]
 
-	query := utils.Concat(insetQuery, " ON DUPLICATE KEY UPDATE ", updateTail)
+	query := utils.Concat(insetQuery, " ON DUPLICATE KEY UPDATE ", strings.Join(updates, ","))
 
 	return query
 }
@@ -0,0 +1,172 @@
+package xorm
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Pius-x/xorm/utils"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+// 字段声明顺序与字典序不同, 用于校验生成的语句按声明顺序排列字段
+type goldenPlayer struct {
+	ID    int64          `db:"id"`
+	Name  string         `db:"name"`
+	Gold  int64          `db:"gold"`
+	Level int32          `db:"level"`
+	Attrs map[string]int `db:"attrs"`
+}
+
+func (goldenPlayer) TableName() string { return "player" }
+
+var goldenTags = utils.GetStructMeta(reflect.TypeOf(goldenPlayer{}), Tag).WriteColumns
+
+// goldenCases 各构建函数的用例, 返回生成的语句及参数
+var goldenCases = map[string]func(cli *Cli) (string, []any, error){
+	"search": func(cli *Cli) (string, []any, error) {
+		query, err := cli.buildSearchQuery("player", goldenTags, "WHERE `id` > ?")
+		return query, nil, err
+	},
+	"insert": func(cli *Cli) (string, []any, error) {
+		return cli.buildInsetQuery("player", goldenTags), nil, nil
+	},
+	"insert_ignore": func(cli *Cli) (string, []any, error) {
+		return cli.buildInsertVerbQuery(insertIgnoreVerb, "player", goldenTags), nil, nil
+	},
+	"insert_values": func(cli *Cli) (string, []any, error) {
+		query, err := cli.buildInsertValuesQuery(replaceVerb, "player", goldenTags, 2)
+		return query, nil, err
+	},
+	"insert_records": func(cli *Cli) (string, []any, error) {
+		return cli.insertQuery(insertVerb, []SqlxTabler{
+			goldenPlayer{ID: 1, Name: "a", Gold: 10, Level: 1},
+			goldenPlayer{ID: 2, Name: "b", Gold: 20, Level: 2, Attrs: map[string]int{"hp": 1}},
+		})
+	},
+	"upsert": func(cli *Cli) (string, []any, error) {
+		return cli.buildUpsertQuery("player", goldenTags, &upsertOptions{}), nil, nil
+	},
+	"upsert_options": func(cli *Cli) (string, []any, error) {
+		opts := &upsertOptions{}
+		for _, opt := range []UpsertOption{UpdateCols("name", "gold", "level"), ExcludeCols("level"), UpdateIncr("gold")} {
+			opt(opts)
+		}
+		return cli.buildUpsertQuery("player", goldenTags, opts), nil, nil
+	},
+	"upsert_alias": func(cli *Cli) (string, []any, error) {
+		opts := &upsertOptions{}
+		for _, opt := range []UpsertOption{ExcludeCols("id"), UpdateIncr("gold"), UpdateExpr("level", "GREATEST(`level`, `new`.`level`)"), RowAlias("new")} {
+			opt(opts)
+		}
+		return cli.buildUpsertQuery("player", goldenTags, opts), nil, nil
+	},
+	"update_struct": func(cli *Cli) (string, []any, error) {
+		updateMap, err := utils.StructToMap(goldenPlayer{ID: 1, Name: "a", Gold: 10, Level: 2}, Tag, true)
+		if err != nil {
+			return "", nil, err
+		}
+		return cli.buildUpdateQuery("player", updateMap, goldenTags, []string{"id"})
+	},
+	"update_map": func(cli *Cli) (string, []any, error) {
+		updateMap := map[string]any{"id": 1, "name": "a", "gold": Expr("`gold` + ?", 5), "level": 2}
+		return cli.buildUpdateQuery("player", updateMap, nil, []string{"id"})
+	},
+	"update_batch": func(cli *Cli) (string, []any, error) {
+		mapSlice := []map[string]any{
+			{"id": 1, "name": "a", "gold": 10, "level": 1},
+			{"id": 2, "level": 3, "gold": Expr("`gold` + ?", 5)},
+		}
+		return cli.buildUpdateBatchQuery("player", mapSlice, goldenTags, "id")
+	},
+	"update_batch_map": func(cli *Cli) (string, []any, error) {
+		mapSlice := []map[string]any{
+			{"id": 1, "zone": 1, "name": "a", "gold": 10},
+			{"id": 2, "zone": 1, "name": "b", "gold": 20},
+		}
+		return cli.buildUpdateBatchQuery("player", mapSlice, nil, "id", "zone")
+	},
+	"incr": func(cli *Cli) (string, []any, error) {
+		query, err := cli.buildIncrQuery("player", []string{"gold", "exp"}, "+", "WHERE `id` = ?")
+		return query, nil, err
+	},
+	"count": func(cli *Cli) (string, []any, error) {
+		return cli.buildCountQuery("player", "WHERE `level` > ?"), nil, nil
+	},
+	"aggregate_sum": func(cli *Cli) (string, []any, error) {
+		return cli.buildAggregateQuery("SUM", "player", "gold", "WHERE `level` > ?"), nil, nil
+	},
+	"aggregate_max": func(cli *Cli) (string, []any, error) {
+		return cli.buildAggregateQuery("MAX", "player", "level", ""), nil, nil
+	},
+	"group": func(cli *Cli) (string, []any, error) {
+		query, err := cli.buildGroupQuery("player", []string{"level", "zone"}, []string{"COUNT(1) AS `count`", "SUM(`gold`) AS `gold`"}, "WHERE `gold` > ?")
+		return query, nil, err
+	},
+	"join": func(cli *Cli) (string, []any, error) {
+		tables := []joinTable{
+			{alias: "p", tb: "player", tags: []string{"id", "name"}},
+			{alias: "i", tb: "item", tags: []string{"id", "player_id"}},
+			{alias: "g", tb: "guild", tags: []string{"id"}},
+		}
+		joins := []joinClause{
+			{kind: innerJoin, alias: "i", on: "i.player_id = p.id"},
+			{kind: leftJoin, alias: "g", on: "g.id = p.guild_id"},
+		}
+		query, err := cli.buildJoinQuery(tables, joins, "WHERE p.level > ?")
+		return query, nil, err
+	},
+	"insert_select": func(cli *Cli) (string, []any, error) {
+		return cli.buildInsertSelectQuery("player_bak", []string{"id", "name"}, "SELECT `id`,`name` FROM player WHERE `level` > ?"), nil, nil
+	},
+	"insert_select_all": func(cli *Cli) (string, []any, error) {
+		return cli.buildInsertSelectQuery("player_bak", nil, "SELECT * FROM player"), nil, nil
+	},
+	"delete": func(cli *Cli) (string, []any, error) {
+		return cli.buildDeleteQuery("player", "WHERE `id` = ?"), nil, nil
+	},
+}
+
+func TestBuildGolden(t *testing.T) {
+	cli := benchCli()
+	for name, build := range goldenCases {
+		t.Run(name, func(t *testing.T) {
+			query, args, err := build(cli)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := fmt.Sprintf("%s\n-- args: %#v\n", query, args)
+
+			// Map 遍历顺序随机, 多次构建的结果需一致
+			for i := 0; i < 20; i++ {
+				query, args, _ = build(cli)
+				if again := fmt.Sprintf("%s\n-- args: %#v\n", query, args); again != got {
+					t.Fatalf("non-deterministic output:\n%s\nvs\n%s", got, again)
+				}
+			}
+
+			path := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err = os.MkdirAll("testdata", 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err = os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create)", err)
+			}
+			if got != string(want) {
+				t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
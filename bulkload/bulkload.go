@@ -0,0 +1,195 @@
+// Package bulkload 流式批量导入, 适用于千万级数据的导入
+//
+// 单独成包, 避免 xorm 包引入 MySQL 及 Postgres 驱动
+package bulkload
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Pius-x/xorm"
+	"github.com/Pius-x/xorm/utils"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+var bulkLoadSeq atomic.Int64
+
+// Load 流式批量导入
+// MySQL 使用 LOAD DATA LOCAL INFILE (需要服务端开启 local_infile), Postgres 使用 COPY FROM STDIN
+// records 同一类型, 同一张表的记录, 复杂字段, 加密字段等与 Insert 一样序列化
+// 返回导入的记录数
+func Load(cli *xorm.Cli, records iter.Seq[xorm.SqlxTabler]) (int64, error) {
+	next, stop := iter.Pull(records)
+	defer stop()
+
+	first, ok := next()
+	if !ok {
+		return 0, nil
+	}
+
+	tb, tags, rows := recordRows(first, next)
+	switch cli.DriverName() {
+	case "mysql":
+		return loadDataInfile(cli, tb, tags, rows)
+	case "postgres":
+		return copyIn(cli, tb, tags, rows)
+	default:
+		return 0, errors.New(fmt.Sprintf("bulk load unsupported driver %s", cli.DriverName()))
+	}
+}
+
+// recordRows 依次取出每条记录转化成按字段顺序排列的值, 表名及字段以第一条记录为准
+// 类型或表名 (如分表) 与第一条记录不同的记录返回错误, 避免写入错误的表或字段
+func recordRows(first xorm.SqlxTabler, next func() (xorm.SqlxTabler, bool)) (string, []string, iter.Seq2[[]any, error]) {
+	typ, tb := reflect.TypeOf(first), first.TableName()
+	tags := utils.GetStructMeta(typ, xorm.Tag).WriteColumns
+
+	rows := func(yield func([]any, error) bool) {
+		for record, ok := first, true; ok; record, ok = next() {
+			if t := reflect.TypeOf(record); t != typ {
+				yield(nil, errors.New(fmt.Sprintf("records expect same type, got %s and %s", typ, t)))
+				return
+			}
+			if name := record.TableName(); name != tb {
+				yield(nil, errors.New(fmt.Sprintf("records expect same table, got %s and %s", tb, name)))
+				return
+			}
+
+			smap, err := utils.StructToMap(record, xorm.Tag, true)
+			if err != nil {
+				yield(nil, errors.WithMessage(err, "转化成Map出错"))
+				return
+			}
+
+			values := make([]any, len(tags))
+			for i, tag := range tags {
+				values[i] = smap[tag]
+			}
+			if !yield(values, nil) {
+				return
+			}
+		}
+	}
+
+	return tb, tags, rows
+}
+
+// loadDataInfile MySQL LOAD DATA LOCAL INFILE 导入, 数据以 CSV 格式流式写入
+func loadDataInfile(cli *xorm.Cli, tb string, tags []string, rows iter.Seq2[[]any, error]) (int64, error) {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w := bufio.NewWriterSize(pw, 64*1024)
+		for values, err := range rows {
+			if err == nil {
+				err = writeCSVRow(w, values)
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(w.Flush())
+	}()
+
+	name := fmt.Sprintf("xorm_bulk_%d", bulkLoadSeq.Add(1))
+	mysql.RegisterReaderHandler(name, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(name)
+	// 提前结束时让写入协程退出, 并等待其不再读取 records
+	defer func() {
+		_ = pr.Close()
+		<-done
+	}()
+
+	result, err := cli.Exec(loadDataQuery(name, tb, tags))
+	if err != nil {
+		return 0, errors.WithMessage(err, "LOAD DATA 语句执行出错")
+	}
+
+	affected, err := result.RowsAffected()
+	return affected, errors.WithStack(err)
+}
+
+// loadDataQuery 构建 LOAD DATA 语句
+// 使用 CHARACTER SET binary 不做字符集转换, 二进制字段 (BLOB, 二进制编解码, 压缩及加密后的数据) 原样写入,
+// 文本字段需为 utf8mb4 (写入的是 UTF-8 字节)
+func loadDataQuery(handler string, tb string, tags []string) string {
+	fields := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		fields = append(fields, utils.Concat("`", tag, "`"))
+	}
+
+	return utils.Concat("LOAD DATA LOCAL INFILE 'Reader::", handler, "' INTO TABLE ", tb,
+		" CHARACTER SET binary FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' ESCAPED BY ''",
+		" LINES TERMINATED BY '\\n' (", strings.Join(fields, ","), ")")
+}
+
+// copyIn Postgres COPY FROM STDIN 导入
+func copyIn(cli *xorm.Cli, tb string, tags []string, rows iter.Seq2[[]any, error]) (int64, error) {
+	tx, err := cli.Begin()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(pq.CopyIn(tb, tags...))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	var count int64
+	for values, err := range rows {
+		if err != nil {
+			_ = stmt.Close()
+			return 0, err
+		}
+		if _, err = stmt.Exec(values...); err != nil {
+			_ = stmt.Close()
+			return 0, errors.WithMessage(err, "COPY 写入出错")
+		}
+		count++
+	}
+
+	if _, err = stmt.Exec(); err != nil {
+		_ = stmt.Close()
+		return 0, errors.WithMessage(err, "COPY 执行出错")
+	}
+	if err = stmt.Close(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return count, errors.WithStack(tx.Commit())
+}
+
+// writeCSVRow 写入一行 CSV, 非 NULL 值均使用双引号包裹, 其中的双引号写为两个双引号, 其余字节 (包括换行及二进制数据) 原样写入
+// NULL 写为不带引号的 NULL: 语句中未设置转义字符, \N 会被当作字符串读取, 而带引号的 "NULL" 为字符串 NULL
+func writeCSVRow(w *bufio.Writer, values []any) error {
+	for i, v := range values {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+
+		s, null, err := utils.FieldString(v)
+		if err != nil {
+			return err
+		}
+		if null {
+			w.WriteString("NULL")
+			continue
+		}
+
+		w.WriteByte('"')
+		w.WriteString(strings.ReplaceAll(s, `"`, `""`))
+		w.WriteByte('"')
+	}
+	_, err := w.WriteString("\n")
+	return err
+}
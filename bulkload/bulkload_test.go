@@ -0,0 +1,162 @@
+package bulkload
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pius-x/xorm"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+type loadPlayer struct {
+	ID    int64          `db:"id"`
+	Name  sql.NullString `db:"name"`
+	Data  []byte         `db:"data"`
+	Attrs map[string]int `db:"attrs"`
+	Shard int
+}
+
+// 按 Shard 分表
+func (p loadPlayer) TableName() string { return fmt.Sprintf("player_%d", p.Shard) }
+
+type loadItem struct {
+	ID int64 `db:"id"`
+}
+
+func (loadItem) TableName() string { return "player_0" }
+
+func TestLoadDataQueryGolden(t *testing.T) {
+	got := loadDataQuery("bulkload_1", "player", []string{"id", "name", "gold", "level", "attrs"}) + "\n"
+
+	path := filepath.Join("testdata", "load_data.golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestWriteCSVRow(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []any
+		want   string
+	}{
+		{"null", []any{nil, []byte(nil), sql.NullString{}}, "NULL,NULL,NULL\n"},
+		{"empty is not null", []any{"", []byte{}, sql.NullString{Valid: true}}, `"","",""` + "\n"},
+		{"word NULL is quoted", []any{"NULL", `\N`}, `"NULL","\N"` + "\n"},
+		{"quote and separators", []any{`a"b`, "a,b", "line1\nline2"}, `"a""b","a,b","line1` + "\n" + `line2"` + "\n"},
+		{"binary", []any{[]byte{0x00, 0xff, '"', '\\', '\n'}}, "\"\x00\xff\"\"\\\n\"\n"},
+		{"scalars", []any{int64(-1), uint8(2), true, false, 1.5, time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)}, `"-1","2","1","0","1.5","2024-01-02 03:04:05.000006"` + "\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := bufio.NewWriter(&buf)
+			if err := writeCSVRow(w, c.values); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+// collectRows 按 Load 的方式取出 records 转化后的值
+func collectRows(records []xorm.SqlxTabler) (string, []string, [][]any, error) {
+	next := func() (xorm.SqlxTabler, bool) {
+		if len(records) == 0 {
+			return nil, false
+		}
+		record := records[0]
+		records = records[1:]
+		return record, true
+	}
+
+	first, _ := next()
+	tb, tags, rows := recordRows(first, next)
+
+	var all [][]any
+	for values, err := range rows {
+		if err != nil {
+			return tb, tags, all, err
+		}
+		all = append(all, values)
+	}
+	return tb, tags, all, nil
+}
+
+func TestRecordRows(t *testing.T) {
+	tb, tags, rows, err := collectRows([]xorm.SqlxTabler{
+		loadPlayer{ID: 1, Name: sql.NullString{String: "a", Valid: true}, Data: []byte{1}, Attrs: map[string]int{"hp": 1}},
+		loadPlayer{ID: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tb != "player_0" || !reflect.DeepEqual(tags, []string{"id", "name", "data", "attrs"}) {
+		t.Errorf("tb = %s tags = %v", tb, tags)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	for _, values := range rows {
+		if err = writeCSVRow(w, values); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = w.Flush()
+	if want := `"1","a","` + "\x01" + `","{""hp"":1}"` + "\n" + `"2",NULL,NULL,"null"` + "\n"; buf.String() != want {
+		t.Errorf("csv = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRecordRowsMixed(t *testing.T) {
+	cases := []struct {
+		name    string
+		records []xorm.SqlxTabler
+		err     string
+	}{
+		{"other type", []xorm.SqlxTabler{loadPlayer{ID: 1}, loadItem{ID: 2}}, "same type"},
+		{"other shard", []xorm.SqlxTabler{loadPlayer{ID: 1}, loadPlayer{ID: 2, Shard: 1}}, "same table"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, rows, err := collectRows(c.records)
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("err = %v, want %q", err, c.err)
+			}
+			if len(rows) != 1 {
+				t.Errorf("got %d rows before error, want 1", len(rows))
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// xorm-cols 读取实现 SqlxTabler 接口的结构体, 为每个模型生成带类型的字段名及条件构建方法
+//
+// 在模型所在的包中添加:
+//
+//	//go:generate go run github.com/Pius-x/xorm/cmd/xorm-cols
+//
+// 生成后可以这样使用:
+//
+//	cond := xorm.And(UserCols.Age.Gt(18), UserCols.Uid.In(1, 2))
+//	err := cli.Search(&users, cond.Where(), cond.Args()...)
+//	_, err = cli.UpdateByStruct(user, UserCols.Uid.Name())
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Pius-x/xorm"
+	"github.com/Pius-x/xorm/utils"
+	"github.com/pkg/errors"
+)
+
+const xormPkg = "github.com/Pius-x/xorm"
+
+type field struct {
+	Name string
+	Type string
+	Col  string
+}
+
+type model struct {
+	Name   string
+	Fields []field
+}
+
+type pkgInfo struct {
+	name    string
+	structs map[string]*ast.StructType
+	tablers map[string]bool
+	files   map[string]*ast.File // 结构体名 -> 声明所在文件
+	imports map[string]string    // 生成代码需要的导入 包名 -> 路径
+}
+
+func main() {
+	var (
+		dir   = flag.String("dir", ".", "模型所在目录")
+		out   = flag.String("out", "xorm_cols_gen.go", "输出文件名, 相对于 dir")
+		types = flag.String("types", "", "需要生成的结构体, 逗号分隔, 为空则生成全部 SqlxTabler")
+	)
+	flag.Parse()
+
+	if err := run(*dir, *out, *types); err != nil {
+		fmt.Fprintf(os.Stderr, "xorm-cols: %+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, out, typeList string) error {
+	info, err := loadPackage(dir, out)
+	if err != nil {
+		return err
+	}
+
+	var filter []string
+	for _, name := range strings.Split(typeList, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			filter = append(filter, name)
+		}
+	}
+
+	names := utils.MapKeys(info.tablers, true)
+	models := make([]model, 0, len(names))
+	for _, name := range names {
+		if len(filter) > 0 && !utils.InSlice(name, filter) {
+			continue
+		}
+		st, ok := info.structs[name]
+		if !ok {
+			continue
+		}
+
+		m := model{Name: name}
+		if err = info.collect(&m, st, info.files[name], 0); err != nil {
+			return errors.WithMessagef(err, "解析结构体 %s 出错", name)
+		}
+		if len(m.Fields) > 0 {
+			models = append(models, m)
+		}
+	}
+
+	if len(models) == 0 {
+		return errors.New("no SqlxTabler struct found")
+	}
+
+	src, err := generate(info, models)
+	if err != nil {
+		return err
+	}
+
+	return errors.WithStack(os.WriteFile(filepath.Join(dir, out), src, 0o644))
+}
+
+// loadPackage 解析目录下的 Go 文件, 收集结构体及实现了 TableName() 的类型
+func loadPackage(dir, out string) (*pkgInfo, error) {
+	fset := token.NewFileSet()
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	info := &pkgInfo{
+		structs: make(map[string]*ast.StructType),
+		tablers: make(map[string]bool),
+		files:   make(map[string]*ast.File),
+		imports: map[string]string{"xorm": xormPkg},
+	}
+
+	for _, path := range matches {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == out {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		info.name = file.Name.Name
+
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					if st, ok := ts.Type.(*ast.StructType); ok && ts.TypeParams == nil {
+						info.structs[ts.Name.Name] = st
+						info.files[ts.Name.Name] = file
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil || d.Name.Name != "TableName" || len(d.Recv.List) != 1 {
+					continue
+				}
+				recv := d.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if ident, ok := recv.(*ast.Ident); ok {
+					info.tablers[ident.Name] = true
+				}
+			}
+		}
+	}
+
+	if info.name == "" {
+		return nil, errors.Errorf("no go files in %s", dir)
+	}
+
+	return info, nil
+}
+
+// collect 收集结构体中带 db 标签的字段, 同包内的匿名嵌入结构体会被展开
+func (info *pkgInfo) collect(m *model, st *ast.StructType, file *ast.File, depth int) error {
+	if depth > 8 {
+		return errors.New("embedded struct too deep")
+	}
+
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			tag = reflect.StructTag(raw)
+		}
+
+		col, _, _ := strings.Cut(tag.Get(xorm.Tag), ",")
+
+		// 匿名嵌入的同包结构体
+		if len(f.Names) == 0 {
+			if ident, ok := f.Type.(*ast.Ident); ok && col == "" {
+				if embedded, ok := info.structs[ident.Name]; ok {
+					if err := info.collect(m, embedded, info.files[ident.Name], depth+1); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+
+		if col == "" || col == "-" {
+			continue
+		}
+
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+			m.Fields = append(m.Fields, field{Name: name.Name, Type: info.typeString(f.Type, file), Col: col})
+		}
+	}
+
+	return nil
+}
+
+// typeString 输出字段类型, 并记录类型中引用的外部包
+func (info *pkgInfo) typeString(expr ast.Expr, file *ast.File) string {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok {
+			for _, imp := range file.Imports {
+				path, _ := strconv.Unquote(imp.Path.Value)
+				name := filepath.Base(path)
+				if imp.Name != nil {
+					name = imp.Name.Name
+				}
+				if name == ident.Name {
+					info.imports[name] = path
+				}
+			}
+		}
+		return false
+	})
+
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+func generate(info *pkgInfo, models []model) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by xorm-cols. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", info.name)
+
+	names := utils.MapKeys(info.imports, false)
+	sort.Slice(names, func(i, j int) bool {
+		return info.imports[names[i]] < info.imports[names[j]]
+	})
+
+	// 标准库在前, 第三方库在后
+	buf.WriteString("import (\n")
+	for _, std := range []bool{true, false} {
+		for _, name := range names {
+			path := info.imports[name]
+			if isStdPkg(path) != std {
+				continue
+			}
+			if filepath.Base(path) == name {
+				fmt.Fprintf(&buf, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+			}
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(")\n\n")
+
+	for _, m := range models {
+		fmt.Fprintf(&buf, "// %sCols %s 表字段\n", m.Name, m.Name)
+		fmt.Fprintf(&buf, "var %sCols = struct {\n", m.Name)
+		for _, f := range m.Fields {
+			fmt.Fprintf(&buf, "\t%s xorm.Col[%s]\n", f.Name, f.Type)
+		}
+		buf.WriteString("}{\n")
+		for _, f := range m.Fields {
+			fmt.Fprintf(&buf, "\t%s: %q,\n", f.Name, f.Col)
+		}
+		buf.WriteString("}\n\n")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "格式化生成代码出错")
+	}
+	return src, nil
+}
+
+func isStdPkg(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+func TestGenerateGolden(t *testing.T) {
+	// 在模块内的临时目录中生成, 以便编译生成的代码
+	dir, err := os.MkdirTemp("testdata", "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := os.ReadFile(filepath.Join("testdata", "sample", "models.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "models.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = run(dir, "xorm_cols_gen.go", ""); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "xorm_cols_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("testdata", "sample.golden")
+	if *update {
+		if err = os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%v (run go test -update to create)", err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", path, got, want)
+		}
+	}
+
+	if testing.Short() {
+		t.Skip("skip compiling generated code in short mode")
+	}
+	out, err := exec.Command("go", "build", "-o", os.DevNull, "./"+filepath.ToSlash(dir)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated code does not compile: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+}
+
+func TestGenerateFilter(t *testing.T) {
+	dir, err := os.MkdirTemp("", "xorm-cols")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := os.ReadFile(filepath.Join("testdata", "sample", "models.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "models.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = run(dir, "cols.go", "Item"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "cols.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "var ItemCols") || strings.Contains(string(got), "UserCols") {
+		t.Errorf("filter Item generated:\n%s", got)
+	}
+
+	if err = run(dir, "cols.go", "Profile"); err == nil {
+		t.Error("expect error for struct without TableName")
+	}
+}
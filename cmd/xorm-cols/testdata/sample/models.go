@@ -0,0 +1,40 @@
+package sample
+
+import (
+	"database/sql"
+	"time"
+
+	jt "github.com/jmoiron/sqlx/types"
+)
+
+type Base struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type User struct {
+	Base
+	Name     string         `db:"name"`
+	Phone    string         `db:"phone,encrypt,blind=phone_bidx"`
+	Extra    jt.JSONText    `db:"extra"`
+	Nick     sql.NullString `db:"nick"`
+	Tags     []string       `db:"tags,codec=msgpack"`
+	Ignored  string         `db:"-"`
+	internal string         `db:"internal"`
+	NoTag    int
+}
+
+func (User) TableName() string { return "user" }
+
+type Item struct {
+	Base
+	Owner, Holder int64          `db:"owner"`
+	Attrs         map[string]int `db:"attrs,compress"`
+}
+
+func (*Item) TableName() string { return "item" }
+
+// 没有实现 TableName 的结构体不生成
+type Profile struct {
+	Bio string `db:"bio"`
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Options 代码生成选项
+type Options struct {
+	Package string
+	Cols    bool // 是否生成带类型的字段名, 与 xorm-cols 生成的 XxxCols 一致
+	Source  string
+}
+
+// 常见的缩写词 生成字段名时保持全大写
+var commonInitialisms = map[string]bool{
+	"API": true, "HTTP": true, "ID": true, "IP": true, "JSON": true,
+	"SQL": true, "UID": true, "URL": true, "UUID": true,
+}
+
+const xormPkg = "github.com/Pius-x/xorm"
+
+// Generate 根据表结构生成实现 SqlxTabler 接口的结构体代码
+func Generate(tables []*Table, opts Options) ([]byte, error) {
+	if err := checkNames(tables, opts); err != nil {
+		return nil, err
+	}
+
+	imports := make(map[string]bool)
+	var body bytes.Buffer
+
+	for _, tb := range tables {
+		structName := toCamel(tb.Name)
+
+		if tb.Comment != "" {
+			fmt.Fprintf(&body, "// %s %s\n", structName, tb.Comment)
+		} else {
+			fmt.Fprintf(&body, "// %s 表 %s\n", structName, tb.Name)
+		}
+		fmt.Fprintf(&body, "type %s struct {\n", structName)
+		for _, col := range tb.Columns {
+			goType, pkg := goTypeOf(col)
+			if pkg != "" {
+				imports[pkg] = true
+			}
+
+			tag := fmt.Sprintf("db:%q", col.Name)
+			if x := xormTagOf(col); x != "" {
+				tag = fmt.Sprintf("%s xorm:%q", tag, x)
+			}
+
+			fmt.Fprintf(&body, "\t%s %s `%s`", fieldName(col.Name), goType, tag)
+			if col.Comment != "" {
+				fmt.Fprintf(&body, " // %s", col.Comment)
+			}
+			body.WriteString("\n")
+		}
+		body.WriteString("}\n\n")
+
+		fmt.Fprintf(&body, "func (%s) TableName() string {\n\treturn %q\n}\n\n", structName, tb.Name)
+
+		if opts.Cols {
+			imports[xormPkg] = true
+			fmt.Fprintf(&body, "// %sCols %s 表字段\n", structName, structName)
+			fmt.Fprintf(&body, "var %sCols = struct {\n", structName)
+			for _, col := range tb.Columns {
+				goType, _ := goTypeOf(col)
+				fmt.Fprintf(&body, "\t%s xorm.Col[%s]\n", fieldName(col.Name), goType)
+			}
+			body.WriteString("}{\n")
+			for _, col := range tb.Columns {
+				fmt.Fprintf(&body, "\t%s: %q,\n", fieldName(col.Name), col.Name)
+			}
+			body.WriteString("}\n\n")
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by xorm-gen. DO NOT EDIT.\n")
+	if opts.Source != "" {
+		fmt.Fprintf(&out, "// source: %s\n", opts.Source)
+	}
+	fmt.Fprintf(&out, "\npackage %s\n\n", opts.Package)
+
+	if len(imports) > 0 {
+		pkgs := make([]string, 0, len(imports))
+		for pkg := range imports {
+			pkgs = append(pkgs, pkg)
+		}
+		sort.Strings(pkgs)
+
+		// 标准库在前, 第三方库在后
+		out.WriteString("import (\n")
+		for _, std := range []bool{true, false} {
+			for _, pkg := range pkgs {
+				if isStdPkg(pkg) == std {
+					fmt.Fprintf(&out, "\t%q\n", pkg)
+				}
+			}
+			out.WriteString("\n")
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "格式化生成代码出错")
+	}
+	return src, nil
+}
+
+// goTypeOf 数据库字段类型映射为 Go 类型, 返回类型名和需要导入的包
+func goTypeOf(col *Column) (string, string) {
+	switch col.DataType {
+	case "tinyint":
+		if col.Length == "1" {
+			return nullable(col, "bool", "sql.NullBool")
+		}
+		return nullable(col, signed(col, "int8"), "sql.NullInt16")
+	case "smallint", "year":
+		return nullable(col, signed(col, "int16"), "sql.NullInt32")
+	case "mediumint", "int", "integer":
+		return nullable(col, signed(col, "int32"), "sql.NullInt64")
+	case "bigint":
+		// sql.NullInt64 无法存放超出 int64 范围的无符号值
+		if col.Unsigned {
+			return nullable(col, "uint64", "sql.Null[uint64]")
+		}
+		return nullable(col, "int64", "sql.NullInt64")
+	case "float":
+		return nullable(col, "float32", "sql.NullFloat64")
+	case "double", "real":
+		return nullable(col, "float64", "sql.NullFloat64")
+	case "decimal", "numeric":
+		// 使用字符串保证精度
+		return nullable(col, "string", "sql.NullString")
+	case "date", "datetime", "timestamp":
+		if col.Nullable {
+			return "sql.NullTime", "database/sql"
+		}
+		return "time.Time", "time"
+	case "json":
+		if col.Nullable {
+			return "types.NullJSONText", "github.com/jmoiron/sqlx/types"
+		}
+		return "types.JSONText", "github.com/jmoiron/sqlx/types"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit":
+		return "[]byte", ""
+	default:
+		// char varchar text enum set time 等
+		return nullable(col, "string", "sql.NullString")
+	}
+}
+
+func nullable(col *Column, typ string, nullTyp string) (string, string) {
+	if col.Nullable && !col.PrimaryKey {
+		return nullTyp, "database/sql"
+	}
+	return typ, ""
+}
+
+func signed(col *Column, typ string) string {
+	if col.Unsigned {
+		return "u" + typ
+	}
+	return typ
+}
+
+// xormTagOf 生成 xorm 标签 如: `xorm:"pk autoincr"`
+func xormTagOf(col *Column) string {
+	var opts []string
+	if col.PrimaryKey {
+		opts = append(opts, "pk")
+	}
+	if col.AutoIncr {
+		opts = append(opts, "autoincr")
+	}
+	if col.Updated {
+		opts = append(opts, "updated")
+	} else if col.Created {
+		opts = append(opts, "created")
+	}
+	return strings.Join(opts, " ")
+}
+
+func isStdPkg(pkg string) bool {
+	return !strings.Contains(strings.SplitN(pkg, "/", 2)[0], ".")
+}
+
+// checkNames 检查生成的类型名, 变量名及字段名是否冲突 如: user_info 与 user-info, user 的 UserCols 与 user_cols
+func checkNames(tables []*Table, opts Options) error {
+	names := make(map[string]string, len(tables)*2)
+	declare := func(name, table string) error {
+		if other, ok := names[name]; ok {
+			return errors.New(fmt.Sprintf("table %s and %s both generate %s", other, table, name))
+		}
+		names[name] = table
+		return nil
+	}
+
+	for _, tb := range tables {
+		structName := toCamel(tb.Name)
+		if err := declare(structName, tb.Name); err != nil {
+			return err
+		}
+		if opts.Cols {
+			if err := declare(structName+"Cols", tb.Name); err != nil {
+				return err
+			}
+		}
+
+		fields := make(map[string]string, len(tb.Columns))
+		for _, col := range tb.Columns {
+			name := fieldName(col.Name)
+			if other, ok := fields[name]; ok {
+				return errors.New(fmt.Sprintf("table %s: column %s and %s both generate field %s", tb.Name, other, col.Name, name))
+			}
+			fields[name] = col.Name
+		}
+	}
+	return nil
+}
+
+// 生成的结构体上已有的方法名, 同名字段会导致编译失败
+var reservedNames = map[string]bool{"TableName": true}
+
+// fieldName 字段名转化为结构体字段名, 与方法同名时加下划线后缀 如: table_name => TableName_
+func fieldName(name string) string {
+	s := toCamel(name)
+	if reservedNames[s] {
+		s += "_"
+	}
+	return s
+}
+
+// toCamel 下划线命名转大驼峰
+func toCamel(name string) string {
+	var builder strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' ' || r == '.'
+	}) {
+		upper := strings.ToUpper(part)
+		if commonInitialisms[upper] {
+			builder.WriteString(upper)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part)
+		builder.WriteRune(unicode.ToUpper(r))
+		builder.WriteString(part[size:])
+	}
+
+	// 以数字或没有大小写的字符 (如中文) 开头时加前缀, 保证字段可导出
+	s := builder.String()
+	if r, _ := utf8.DecodeRuneInString(s); !unicode.IsUpper(r) {
+		s = "T" + s
+	}
+	return s
+}
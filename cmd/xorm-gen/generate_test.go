@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+func TestGoTypeOf(t *testing.T) {
+	cases := []struct {
+		col Column
+		typ string
+		pkg string
+	}{
+		{Column{DataType: "tinyint", Length: "1"}, "bool", ""},
+		{Column{DataType: "tinyint", Length: "1", Nullable: true}, "sql.NullBool", "database/sql"},
+		{Column{DataType: "tinyint", Unsigned: true}, "uint8", ""},
+		{Column{DataType: "tinyint", Nullable: true}, "sql.NullInt16", "database/sql"},
+		{Column{DataType: "smallint"}, "int16", ""},
+		{Column{DataType: "int"}, "int32", ""},
+		{Column{DataType: "int", Unsigned: true, Nullable: true}, "sql.NullInt64", "database/sql"},
+		{Column{DataType: "bigint"}, "int64", ""},
+		{Column{DataType: "bigint", Unsigned: true}, "uint64", ""},
+		{Column{DataType: "bigint", Nullable: true}, "sql.NullInt64", "database/sql"},
+		{Column{DataType: "bigint", Unsigned: true, Nullable: true}, "sql.Null[uint64]", "database/sql"},
+		{Column{DataType: "bigint", Unsigned: true, Nullable: true, PrimaryKey: true}, "uint64", ""},
+		{Column{DataType: "float"}, "float32", ""},
+		{Column{DataType: "double", Nullable: true}, "sql.NullFloat64", "database/sql"},
+		{Column{DataType: "decimal"}, "string", ""},
+		{Column{DataType: "datetime"}, "time.Time", "time"},
+		{Column{DataType: "timestamp", Nullable: true}, "sql.NullTime", "database/sql"},
+		{Column{DataType: "json"}, "types.JSONText", "github.com/jmoiron/sqlx/types"},
+		{Column{DataType: "json", Nullable: true}, "types.NullJSONText", "github.com/jmoiron/sqlx/types"},
+		{Column{DataType: "varbinary", Nullable: true}, "[]byte", ""},
+		{Column{DataType: "varchar"}, "string", ""},
+		{Column{DataType: "enum", Nullable: true}, "sql.NullString", "database/sql"},
+	}
+
+	for _, c := range cases {
+		typ, pkg := goTypeOf(&c.col)
+		if typ != c.typ || pkg != c.pkg {
+			t.Errorf("goTypeOf(%+v) = %s %q, want %s %q", c.col, typ, pkg, c.typ, c.pkg)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	cases := []struct {
+		name  string
+		field string
+	}{
+		{"user", "User"},
+		{"user_id", "UserID"},
+		{"http_url", "HTTPURL"},
+		{"item-count", "ItemCount"},
+		{"table_name", "TableName_"},
+		{"2fa_code", "T2faCode"},
+		{"名字", "T名字"},
+		{"ëlan", "Ëlan"},
+		{"_private", "Private"},
+	}
+
+	for _, c := range cases {
+		if got := fieldName(c.name); got != c.field {
+			t.Errorf("fieldName(%q) = %q, want %q", c.name, got, c.field)
+		}
+	}
+}
+
+func TestGenerateNameCollision(t *testing.T) {
+	cols := []*Column{{Name: "id", DataType: "int"}}
+	cases := []struct {
+		names []string
+		cols  bool
+		err   bool
+	}{
+		{[]string{"user", "user_table"}, true, false},
+		{[]string{"user", "user_cols"}, false, false},
+		{[]string{"user", "user_cols"}, true, true},
+		{[]string{"user_info", "user-info"}, false, true},
+	}
+
+	for _, c := range cases {
+		tables := make([]*Table, 0, len(c.names))
+		for _, name := range c.names {
+			tables = append(tables, &Table{Name: name, Columns: cols})
+		}
+		_, err := Generate(tables, Options{Package: "model", Cols: c.cols})
+		if (err != nil) != c.err {
+			t.Errorf("Generate(%v, cols=%v) err = %v, want error %v", c.names, c.cols, err, c.err)
+		}
+	}
+
+	_, err := Generate([]*Table{{Name: "a", Columns: []*Column{{Name: "a_b", DataType: "int"}, {Name: "a__b", DataType: "int"}}}}, Options{Package: "model"})
+	if err == nil {
+		t.Error("expect error for duplicate field names")
+	}
+}
+
+func TestGenerateGolden(t *testing.T) {
+	tables, err := ParseCreateTables(testDDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables = append(tables, &Table{Name: "名字", Columns: []*Column{
+		{Name: "id", DataType: "bigint", Unsigned: true, PrimaryKey: true},
+		{Name: "table_name", DataType: "varchar"},
+		{Name: "balance", DataType: "bigint", Unsigned: true, Nullable: true},
+	}})
+
+	src, err := Generate(tables, Options{Package: "model", Cols: true, Source: "schema.sql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("testdata", "model.golden")
+	if *update {
+		if err = os.WriteFile(path, src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%v (run go test -update to create)", err)
+		}
+		if string(src) != string(want) {
+			t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", path, src, want)
+		}
+	}
+
+	buildGenerated(t, src)
+}
+
+// buildGenerated 在模块内的临时目录中编译生成的代码
+func buildGenerated(t *testing.T, src []byte) {
+	if testing.Short() {
+		t.Skip("skip compiling generated code in short mode")
+	}
+
+	dir, err := os.MkdirTemp("testdata", "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = os.WriteFile(filepath.Join(dir, "model.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("go", "build", "-o", os.DevNull, "./"+filepath.ToSlash(dir)).CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated code does not compile: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+}
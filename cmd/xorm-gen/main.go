@@ -0,0 +1,135 @@
+// xorm-gen 根据已有的数据库表结构生成实现 SqlxTabler 接口的结构体
+//
+// 在线模式, 通过 DSN 连接数据库读取建表语句:
+//
+//	xorm-gen -dsn "user:pwd@tcp(127.0.0.1:3306)/game" -tables player,item -pkg model -out model/tables.go
+//
+// 离线模式, 读取 SHOW CREATE TABLE 导出的文件:
+//
+//	xorm-gen -file schema.sql -pkg model -cols
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Pius-x/xorm/utils"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+func main() {
+	var (
+		dsn    = flag.String("dsn", "", "MySQL DSN, 如: user:pwd@tcp(127.0.0.1:3306)/db")
+		file   = flag.String("file", "", "SHOW CREATE TABLE 导出的文件, 设置后不连接数据库")
+		tables = flag.String("tables", "", "需要生成的表, 逗号分隔, 为空则生成全部")
+		pkg    = flag.String("pkg", "model", "生成代码的包名")
+		out    = flag.String("out", "", "输出文件, 为空则输出到标准输出")
+		cols   = flag.Bool("cols", false, "是否生成带类型的字段名 (同 xorm-cols)")
+	)
+	flag.Parse()
+
+	if err := run(*dsn, *file, *tables, *pkg, *out, *cols); err != nil {
+		fmt.Fprintf(os.Stderr, "xorm-gen: %+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dsn, file, tableList, pkg, out string, cols bool) error {
+	var filter []string
+	for _, name := range strings.Split(tableList, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			filter = append(filter, name)
+		}
+	}
+
+	var ddl, source string
+	var err error
+	switch {
+	case file != "":
+		var data []byte
+		if data, err = os.ReadFile(file); err != nil {
+			return errors.WithStack(err)
+		}
+		ddl, source = string(data), file
+	case dsn != "":
+		if ddl, err = loadDDL(dsn, filter); err != nil {
+			return err
+		}
+	default:
+		return errors.New("either -dsn or -file is required")
+	}
+
+	tables, err := ParseCreateTables(ddl)
+	if err != nil {
+		return errors.WithMessage(err, "解析建表语句出错")
+	}
+
+	if len(filter) > 0 {
+		kept := tables[:0]
+		for _, tb := range tables {
+			if utils.InSlice(tb.Name, filter) {
+				kept = append(kept, tb)
+			}
+		}
+		tables = kept
+	}
+
+	if len(tables) == 0 {
+		return errors.New("no table found")
+	}
+
+	src, err := Generate(tables, Options{Package: pkg, Cols: cols, Source: source})
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(out, src, 0o644))
+}
+
+// loadDDL 连接数据库获取建表语句
+func loadDDL(dsn string, tables []string) (string, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer db.Close()
+
+	if len(tables) == 0 {
+		rows, err := db.Query("SHOW TABLES")
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		for rows.Next() {
+			var name string
+			if err = rows.Scan(&name); err != nil {
+				rows.Close()
+				return "", errors.WithStack(err)
+			}
+			tables = append(tables, name)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	var builder strings.Builder
+	for _, tb := range tables {
+		var name, ddl string
+		if err = db.QueryRow(utils.Concat("SHOW CREATE TABLE `", tb, "`")).Scan(&name, &ddl); err != nil {
+			return "", errors.Wrapf(err, "SHOW CREATE TABLE %s", tb)
+		}
+		builder.WriteString(ddl)
+		builder.WriteString(";\n\n")
+	}
+
+	return builder.String(), nil
+}
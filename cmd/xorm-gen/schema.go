@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Table 表结构
+type Table struct {
+	Name    string
+	Comment string
+	Columns []*Column
+}
+
+// Column 字段结构
+type Column struct {
+	Name       string
+	DataType   string // 小写的基础类型 如: int varchar json
+	Length     string // 括号中的长度 如: 11 255 10,2
+	Unsigned   bool
+	Nullable   bool
+	PrimaryKey bool
+	AutoIncr   bool
+	Created    bool // DEFAULT CURRENT_TIMESTAMP
+	Updated    bool // ON UPDATE CURRENT_TIMESTAMP
+	Comment    string
+}
+
+var (
+	createTableRe = regexp.MustCompile("(?i)^\\s*CREATE\\s+TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?([\\w.`]+?)\\s*\\(")
+	columnRe      = regexp.MustCompile("^\\s*`([^`]+)`\\s+([a-zA-Z]+)(?:\\(([^)]*)\\))?(.*)$")
+	primaryKeyRe  = regexp.MustCompile("(?i)^\\s*PRIMARY\\s+KEY\\s*\\((.*)\\)")
+	commentRe     = regexp.MustCompile("(?i)COMMENT\\s*=?\\s*'((?:[^'\\\\]|\\\\.|'')*)'")
+	tableEndRe    = regexp.MustCompile("^\\s*\\)(.*?);?\\s*$")
+	createdRe     = regexp.MustCompile(`DEFAULT\s+(CURRENT_TIMESTAMP|NOW\(\))`)
+	updatedRe     = regexp.MustCompile(`ON\s+UPDATE\s+(CURRENT_TIMESTAMP|NOW\(\))`)
+)
+
+// ParseCreateTables 解析 SHOW CREATE TABLE 输出的建表语句 (支持多张表)
+func ParseCreateTables(ddl string) ([]*Table, error) {
+	var tables []*Table
+	var cur *Table
+
+	scanner := bufio.NewScanner(strings.NewReader(ddl))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if cur == nil {
+			if m := createTableRe.FindStringSubmatch(line); m != nil {
+				// 可能带库名 如: `game`.`player`
+				name := strings.ReplaceAll(m[1], "`", "")
+				if i := strings.LastIndex(name, "."); i >= 0 {
+					name = name[i+1:]
+				}
+				cur = &Table{Name: name}
+			}
+			continue
+		}
+
+		if m := tableEndRe.FindStringSubmatch(line); m != nil {
+			if c := commentRe.FindStringSubmatch(m[1]); c != nil {
+				cur.Comment = unquote(c[1])
+			}
+			tables = append(tables, cur)
+			cur = nil
+			continue
+		}
+
+		if m := columnRe.FindStringSubmatch(line); m != nil {
+			cur.Columns = append(cur.Columns, parseColumn(m))
+			continue
+		}
+
+		if m := primaryKeyRe.FindStringSubmatch(line); m != nil {
+			for _, name := range strings.Split(m[1], ",") {
+				// 前缀索引 如: `name`(8)
+				if i := strings.Index(name, "("); i >= 0 {
+					name = name[:i]
+				}
+				name = strings.Trim(strings.TrimSpace(name), "`")
+				for _, col := range cur.Columns {
+					if col.Name == name {
+						col.PrimaryKey = true
+					}
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if cur != nil {
+		return nil, errors.Errorf("table %s: unexpected end of statement", cur.Name)
+	}
+
+	return tables, nil
+}
+
+func parseColumn(m []string) *Column {
+	rest := m[4]
+	// 注释中的内容不参与属性判断
+	upper := strings.ToUpper(commentRe.ReplaceAllString(rest, ""))
+
+	col := &Column{
+		Name:     m[1],
+		DataType: strings.ToLower(m[2]),
+		Length:   strings.ReplaceAll(m[3], " ", ""),
+		Unsigned: strings.Contains(upper, "UNSIGNED"),
+		Nullable: !strings.Contains(upper, "NOT NULL"),
+		AutoIncr: strings.Contains(upper, "AUTO_INCREMENT"),
+		Created:  createdRe.MatchString(upper),
+		Updated:  updatedRe.MatchString(upper),
+	}
+
+	if strings.Contains(upper, "PRIMARY KEY") {
+		col.PrimaryKey = true
+	}
+
+	if c := commentRe.FindStringSubmatch(rest); c != nil {
+		col.Comment = unquote(c[1])
+	}
+
+	return col
+}
+
+func unquote(s string) string {
+	s = strings.ReplaceAll(s, "''", "'")
+	s = strings.ReplaceAll(s, "\\'", "'")
+	s = strings.ReplaceAll(s, "\\n", " ")
+	return s
+}
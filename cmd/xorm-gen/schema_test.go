@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testDDL = "CREATE TABLE `player` (\n" +
+	"  `id` bigint unsigned NOT NULL AUTO_INCREMENT COMMENT '玩家ID',\n" +
+	"  `name` varchar(64) NOT NULL DEFAULT '' COMMENT 'it''s name, NOT NULL',\n" +
+	"  `gold` decimal(20, 2) DEFAULT NULL,\n" +
+	"  `vip` tinyint(1) NOT NULL DEFAULT '0',\n" +
+	"  `attrs` json DEFAULT NULL,\n" +
+	"  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+	"  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  KEY `idx_name` (`name`)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='玩家表';\n" +
+	"\n" +
+	"CREATE TABLE IF NOT EXISTS `game`.`item` (\n" +
+	"  `player_id` int NOT NULL,\n" +
+	"  `item_id` int NOT NULL,\n" +
+	"  PRIMARY KEY (`player_id`,`item_id`(8))\n" +
+	");\n"
+
+func TestParseCreateTables(t *testing.T) {
+	tables, err := ParseCreateTables(testDDL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("got %d tables, want 2", len(tables))
+	}
+
+	player := tables[0]
+	if player.Name != "player" || player.Comment != "玩家表" {
+		t.Errorf("table = %q %q", player.Name, player.Comment)
+	}
+
+	want := []Column{
+		{Name: "id", DataType: "bigint", Unsigned: true, PrimaryKey: true, AutoIncr: true, Comment: "玩家ID"},
+		{Name: "name", DataType: "varchar", Length: "64", Comment: "it's name, NOT NULL"},
+		{Name: "gold", DataType: "decimal", Length: "20,2", Nullable: true},
+		{Name: "vip", DataType: "tinyint", Length: "1"},
+		{Name: "attrs", DataType: "json", Nullable: true},
+		{Name: "created_at", DataType: "datetime", Created: true},
+		{Name: "updated_at", DataType: "datetime", Nullable: true, Created: true, Updated: true},
+	}
+	if len(player.Columns) != len(want) {
+		t.Fatalf("got %d columns, want %d", len(player.Columns), len(want))
+	}
+	for i, col := range player.Columns {
+		if !reflect.DeepEqual(*col, want[i]) {
+			t.Errorf("column %d = %+v, want %+v", i, *col, want[i])
+		}
+	}
+
+	item := tables[1]
+	if item.Name != "item" {
+		t.Errorf("table name = %q, want item", item.Name)
+	}
+	for _, col := range item.Columns {
+		if !col.PrimaryKey {
+			t.Errorf("column %s should be primary key", col.Name)
+		}
+	}
+}
+
+func TestParseCreateTablesUnterminated(t *testing.T) {
+	if _, err := ParseCreateTables("CREATE TABLE `a` (\n  `id` int NOT NULL,\n"); err == nil {
+		t.Fatal("expect error for unterminated statement")
+	}
+}
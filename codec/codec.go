@@ -0,0 +1,197 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// 内置编解码器名称
+const (
+	JSON     = "json"     // bytedance/sonic
+	StdJSON  = "stdjson"  // encoding/json
+	Msgpack  = "msgpack"  // MessagePack
+	Gob      = "gob"      // encoding/gob
+	Protobuf = "protobuf" // 字段类型需实现 proto.Message
+)
+
+// Codec 复杂字段 (结构体,切片,Map等) 的编解码器
+// 字段通过 db 标签的 codec 选项指定 如: `db:"attrs,codec=msgpack"`, 未指定时使用默认编解码器
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// Binary 编码结果为二进制数据的编解码器实现该接口, 写入时使用 []byte 而不是字符串
+type Binary interface {
+	Binary() bool
+}
+
+var (
+	mu       sync.RWMutex
+	codecs   = map[string]Codec{}
+	fallback Codec
+)
+
+func init() {
+	Register(sonicCodec{})
+	Register(stdJSONCodec{})
+	Register(msgpackCodec{})
+	Register(gobCodec{})
+	Register(protobufCodec{})
+	fallback = sonicCodec{}
+}
+
+// Register 注册编解码器, 同名的会被覆盖
+func Register(c Codec) {
+	mu.Lock()
+	defer mu.Unlock()
+	codecs[c.Name()] = c
+}
+
+// Get 获取指定名称的编解码器, name 为空时返回默认编解码器
+func Get(name string) (Codec, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if name == "" {
+		return fallback, nil
+	}
+	c, ok := codecs[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("codec %s not registered", name))
+	}
+	return c, nil
+}
+
+// Default 默认编解码器
+func Default() Codec {
+	mu.RLock()
+	defer mu.RUnlock()
+	return fallback
+}
+
+// SetDefault 设置默认编解码器, 需先注册
+// 注意: 修改默认编解码器后, 已按原编解码器写入的数据需显式指定 codec 选项才能读取
+func SetDefault(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	c, ok := codecs[name]
+	if !ok {
+		return errors.New(fmt.Sprintf("codec %s not registered", name))
+	}
+	fallback = c
+	return nil
+}
+
+// IsBinary 编解码器的编码结果是否为二进制数据
+func IsBinary(c Codec) bool {
+	b, ok := c.(Binary)
+	return ok && b.Binary()
+}
+
+type sonicCodec struct{}
+
+func (sonicCodec) Name() string { return JSON }
+
+func (sonicCodec) Marshal(v any) ([]byte, error) {
+	data, err := sonic.Marshal(v)
+	return data, errors.WithStack(err)
+}
+
+func (sonicCodec) Unmarshal(data []byte, v any) error {
+	return errors.WithStack(sonic.Unmarshal(data, v))
+}
+
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Name() string { return StdJSON }
+
+func (stdJSONCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	return data, errors.WithStack(err)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v any) error {
+	return errors.WithStack(json.Unmarshal(data, v))
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return Msgpack }
+
+func (msgpackCodec) Binary() bool { return true }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	data, err := msgpack.Marshal(v)
+	return data, errors.WithStack(err)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return errors.WithStack(msgpack.Unmarshal(data, v))
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return Gob }
+
+func (gobCodec) Binary() bool { return true }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return errors.WithStack(gob.NewDecoder(bytes.NewReader(data)).Decode(v))
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return Protobuf }
+
+func (protobufCodec) Binary() bool { return true }
+
+// Marshal v 为 proto.Message 或其指针 (字段类型为 *pb.Msg 时传入的是 *pb.Msg)
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("%T does not implement proto.Message", v))
+	}
+	data, err := proto.Marshal(msg)
+	return data, errors.WithStack(err)
+}
+
+// Unmarshal v 为 proto.Message 或指向 proto.Message 的指针 (字段类型为 *pb.Msg 时传入的是 **pb.Msg)
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case proto.Message:
+		return errors.WithStack(proto.Unmarshal(data, m))
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Ptr {
+			return errors.New(fmt.Sprintf("%T does not implement proto.Message", v))
+		}
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		msg, ok := rv.Elem().Interface().(proto.Message)
+		if !ok {
+			return errors.New(fmt.Sprintf("%T does not implement proto.Message", v))
+		}
+		return errors.WithStack(proto.Unmarshal(data, msg))
+	}
+}
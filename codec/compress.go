@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// 内置压缩算法名称, 字段通过 db 标签的 compress 选项指定 如: `db:"state,compress=zstd"`
+const (
+	Gzip   = "gzip"
+	Zstd   = "zstd"
+	Snappy = "snappy"
+)
+
+// 压缩数据的头部: magic + 算法标识, 没有该头部的数据按未压缩处理, 以兼容旧数据
+var compressMagic = []byte{0x00, 'X', 'Z'}
+
+var compressIDs = map[string]byte{Gzip: 'g', Zstd: 'z', Snappy: 's'}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// Compress 使用指定算法压缩数据, 并加上头部
+func Compress(name string, data []byte) ([]byte, error) {
+	id, ok := compressIDs[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("compress %s not supported", name))
+	}
+
+	dst := make([]byte, 0, len(compressMagic)+1+len(data)/2)
+	dst = append(append(dst, compressMagic...), id)
+
+	switch name {
+	case Gzip:
+		buf := bytes.NewBuffer(dst)
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		return zstdEncoder.EncodeAll(data, dst), nil
+	default:
+		return append(dst, s2.EncodeSnappy(nil, data)...), nil
+	}
+}
+
+// Decompress 解压带头部的数据, 没有头部的数据原样返回
+func Decompress(data []byte) ([]byte, error) {
+	if !IsCompressed(data) {
+		return data, nil
+	}
+
+	id, payload := data[len(compressMagic)], data[len(compressMagic)+1:]
+	switch id {
+	case compressIDs[Gzip]:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		defer r.Close()
+		out, err := io.ReadAll(r)
+		return out, errors.WithStack(err)
+	case compressIDs[Zstd]:
+		out, err := zstdDecoder.DecodeAll(payload, nil)
+		return out, errors.WithStack(err)
+	case compressIDs[Snappy]:
+		out, err := s2.Decode(nil, payload)
+		return out, errors.WithStack(err)
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown compress id %q", id))
+	}
+}
+
+// IsCompressed 数据是否带有压缩头部
+func IsCompressed(data []byte) bool {
+	return len(data) > len(compressMagic) && bytes.HasPrefix(data, compressMagic)
+}
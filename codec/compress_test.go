@@ -0,0 +1,45 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"quest":1,"state":"done"}`), 100)
+	for _, name := range []string{Gzip, Zstd, Snappy} {
+		compressed, err := Compress(name, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsCompressed(compressed) || compressed[len(compressMagic)] != compressIDs[name] || len(compressed) >= len(data) {
+			t.Errorf("%s: compressed = %x", name, compressed[:8])
+		}
+
+		got, err := Decompress(compressed)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: Decompress = %v", name, err)
+		}
+	}
+
+	if _, err := Compress("lz4", data); err == nil {
+		t.Error("expect error for unsupported compress")
+	}
+}
+
+func TestDecompressUncompressed(t *testing.T) {
+	// 未压缩的旧数据原样返回
+	for _, data := range [][]byte{nil, []byte(`{"a":1}`), compressMagic} {
+		got, err := Decompress(data)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("Decompress(%q) = %q, %v", data, got, err)
+		}
+	}
+
+	if _, err := Decompress(append(append([]byte{}, compressMagic...), 'x', 1)); err == nil {
+		t.Error("expect error for unknown compress id")
+	}
+	if _, err := Decompress(append(append([]byte{}, compressMagic...), 'z', 1, 2, 3)); err == nil {
+		t.Error("expect error for corrupted data")
+	}
+}
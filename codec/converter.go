@@ -0,0 +1,30 @@
+package codec
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Converter 自定义类型与数据库值之间的转换器, 优先于 Scanner/Valuer 以及复杂类型的编解码器
+type Converter struct {
+	// ToDB 转化为写入数据库的值 (int64 float64 bool []byte string time.Time nil 等驱动支持的类型)
+	ToDB func(v any) (any, error)
+	// FromDB 将驱动读取到的值转化为字段类型, NULL 时 src 为 nil, 加密字段读取到的是解密后的 []byte
+	FromDB func(src any) (any, error)
+}
+
+var converters sync.Map // reflect.Type => *Converter
+
+// RegisterConverter 注册类型转换器, 同一类型重复注册会覆盖
+func RegisterConverter(typ reflect.Type, c Converter) {
+	converters.Store(typ, &c)
+}
+
+// LookupConverter 获取类型的转换器
+func LookupConverter(typ reflect.Type) (*Converter, bool) {
+	c, ok := converters.Load(typ)
+	if !ok {
+		return nil, false
+	}
+	return c.(*Converter), true
+}
@@ -0,0 +1,186 @@
+package codec
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// KeyProvider 字段加密的密钥提供者, 字段通过 db 标签的 encrypt 选项开启加密 如: `db:"phone,encrypt"`
+// 密文中带有密钥 ID, 轮换密钥后旧数据仍使用原密钥解密
+type KeyProvider interface {
+	// CurrentKey 当前用于加密的密钥 (16/24/32 字节) 及其 ID
+	CurrentKey() (id string, key []byte, err error)
+	// Key 根据 ID 获取解密用的密钥
+	Key(id string) ([]byte, error)
+	// IndexKey 盲索引使用的 HMAC 密钥, 不应随加密密钥轮换
+	IndexKey() ([]byte, error)
+}
+
+// StaticKeys 固定密钥的 KeyProvider
+type StaticKeys struct {
+	Current string            // 当前加密使用的密钥 ID
+	Keys    map[string][]byte // 密钥 ID => 密钥
+	Index   []byte            // 盲索引密钥
+}
+
+// CurrentKey 当前用于加密的密钥及其 ID
+func (s StaticKeys) CurrentKey() (string, []byte, error) {
+	key, err := s.Key(s.Current)
+	return s.Current, key, err
+}
+
+// Key 根据 ID 获取解密用的密钥
+func (s StaticKeys) Key(id string) ([]byte, error) {
+	key, ok := s.Keys[id]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("encrypt key %s not found", id))
+	}
+	return key, nil
+}
+
+// IndexKey 盲索引使用的 HMAC 密钥
+func (s StaticKeys) IndexKey() ([]byte, error) {
+	if len(s.Index) == 0 {
+		return nil, errors.New("blind index key is empty")
+	}
+	return s.Index, nil
+}
+
+// 密文格式: magic + 密钥 ID 长度(1字节) + 密钥 ID + nonce + AES-GCM 密文
+// 没有该头部的数据按明文处理, 以兼容开启加密前写入的数据
+// 加密字段以 表名.字段名 作为 GCM 的附加认证数据, 密文被挪到其他表或字段后无法解密
+var encryptMagic = []byte{0x00, 'X', 'E'}
+
+var (
+	keyMu       sync.RWMutex
+	keyProvider KeyProvider
+)
+
+// SetKeyProvider 设置字段加密的密钥提供者
+func SetKeyProvider(p KeyProvider) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	keyProvider = p
+}
+
+func getKeyProvider() (KeyProvider, error) {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	if keyProvider == nil {
+		return nil, errors.New("key provider not set, call codec.SetKeyProvider first")
+	}
+	return keyProvider, nil
+}
+
+// FieldAAD 加密字段的附加认证数据 表名.字段名
+func FieldAAD(table, column string) []byte {
+	return []byte(table + "." + column)
+}
+
+// Encrypt 使用当前密钥加密, aad 为附加认证数据, 解密时需要传入相同的值
+func Encrypt(plain []byte, aad []byte) ([]byte, error) {
+	p, err := getKeyProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	id, key, err := p.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, errors.New(fmt.Sprintf("encrypt key id %s too long", id))
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := make([]byte, 0, len(encryptMagic)+1+len(id)+gcm.NonceSize()+len(plain)+gcm.Overhead())
+	dst = append(append(dst, encryptMagic...), byte(len(id)))
+	dst = append(dst, id...)
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	dst = append(dst, nonce...)
+
+	return gcm.Seal(dst, nonce, plain, aad), nil
+}
+
+// Decrypt 按密文中的密钥 ID 解密, 不是密文的数据原样返回, aad 与加密时不同则返回错误
+func Decrypt(data []byte, aad []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	p, err := getKeyProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	rest := data[len(encryptMagic):]
+	idLen := int(rest[0])
+	if len(rest) < 1+idLen {
+		return nil, errors.New("invalid ciphertext")
+	}
+	id, rest := string(rest[1:1+idLen]), rest[1+idLen:]
+
+	key, err := p.Key(id)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
+	}
+
+	plain, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], aad)
+	return plain, errors.WithStack(err)
+}
+
+// IsEncrypted 数据是否带有密文头部
+func IsEncrypted(data []byte) bool {
+	return len(data) > len(encryptMagic) && bytes.HasPrefix(data, encryptMagic)
+}
+
+// BlindIndex 计算明文的盲索引 (HMAC-SHA256 十六进制), 用于加密字段的等值查询
+func BlindIndex(plain []byte) (string, error) {
+	p, err := getKeyProvider()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := p.IndexKey()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(plain)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	return gcm, errors.WithStack(err)
+}
@@ -0,0 +1,154 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testKeys(current string) StaticKeys {
+	return StaticKeys{
+		Current: current,
+		Keys: map[string][]byte{
+			"k1": bytes.Repeat([]byte{1}, 32),
+			"k2": bytes.Repeat([]byte{2}, 16),
+		},
+		Index: []byte("index"),
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	SetKeyProvider(testKeys("k1"))
+	aad := FieldAAD("user", "phone")
+
+	for _, plain := range [][]byte{[]byte("13800000000"), {}, {0x00, 'X', 'E', 0xff}} {
+		cipher, err := Encrypt(plain, aad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(cipher) || bytes.Contains(cipher, []byte("13800000000")) {
+			t.Fatalf("cipher = %x", cipher)
+		}
+
+		got, err := Decrypt(cipher, aad)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("Decrypt = %q, %v, want %q", got, err, plain)
+		}
+	}
+
+	// 每次加密使用不同的 nonce
+	a, _ := Encrypt([]byte("a"), aad)
+	b, _ := Encrypt([]byte("a"), aad)
+	if bytes.Equal(a, b) {
+		t.Error("same ciphertext for same plaintext")
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	SetKeyProvider(testKeys("k1"))
+	aad := FieldAAD("user", "phone")
+	cipher, err := Encrypt([]byte("13800000000"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 挪到其他字段或表
+	for _, other := range [][]byte{FieldAAD("user", "email"), FieldAAD("admin", "phone"), nil} {
+		if _, err = Decrypt(cipher, other); err == nil {
+			t.Errorf("Decrypt with aad %q expect error", other)
+		}
+	}
+
+	// 修改密文的任意字节 (头部之后)
+	for i := len(encryptMagic); i < len(cipher); i++ {
+		tampered := append([]byte(nil), cipher...)
+		tampered[i] ^= 0x01
+		if _, err = Decrypt(tampered, aad); err == nil {
+			t.Errorf("Decrypt with byte %d flipped expect error", i)
+		}
+	}
+
+	// 截断
+	for _, n := range []int{len(encryptMagic) + 1, len(encryptMagic) + 3, len(cipher) - 1} {
+		if _, err = Decrypt(cipher[:n], aad); err == nil {
+			t.Errorf("Decrypt truncated to %d expect error", n)
+		}
+	}
+}
+
+func TestDecryptKeyRotation(t *testing.T) {
+	SetKeyProvider(testKeys("k1"))
+	aad := FieldAAD("user", "phone")
+	old, err := Encrypt([]byte("old"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换后新数据使用新密钥, 旧数据仍可解密
+	SetKeyProvider(testKeys("k2"))
+	cur, err := Encrypt([]byte("new"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(cur, []byte("k2")) {
+		t.Errorf("cipher %x should carry key id k2", cur)
+	}
+	for cipher, want := range map[string]string{string(old): "old", string(cur): "new"} {
+		if got, err := Decrypt([]byte(cipher), aad); err != nil || string(got) != want {
+			t.Errorf("Decrypt = %q, %v, want %q", got, err, want)
+		}
+	}
+
+	// 旧密钥被移除后无法解密
+	keys := testKeys("k2")
+	delete(keys.Keys, "k1")
+	SetKeyProvider(keys)
+	if _, err = Decrypt(old, aad); err == nil {
+		t.Error("expect error for removed key")
+	}
+}
+
+func TestDecryptPlaintext(t *testing.T) {
+	SetKeyProvider(testKeys("k1"))
+	// 开启加密前写入的明文原样返回
+	for _, data := range [][]byte{nil, []byte("13800000000"), encryptMagic} {
+		got, err := Decrypt(data, nil)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("Decrypt(%q) = %q, %v", data, got, err)
+		}
+	}
+}
+
+func TestEncryptNoProvider(t *testing.T) {
+	SetKeyProvider(nil)
+	defer SetKeyProvider(testKeys("k1"))
+
+	if _, err := Encrypt([]byte("a"), nil); err == nil {
+		t.Error("Encrypt expect error without key provider")
+	}
+	if _, err := BlindIndex([]byte("a")); err == nil {
+		t.Error("BlindIndex expect error without key provider")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	SetKeyProvider(testKeys("k1"))
+	a, err := BlindIndex([]byte("13800000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := BlindIndex([]byte("13800000000")); a != b || len(a) != 64 {
+		t.Errorf("BlindIndex = %s, %s", a, b)
+	}
+
+	// 与加密密钥轮换无关, 与盲索引密钥相关
+	SetKeyProvider(testKeys("k2"))
+	if b, _ := BlindIndex([]byte("13800000000")); a != b {
+		t.Error("blind index changed after key rotation")
+	}
+	keys := testKeys("k1")
+	keys.Index = []byte("other")
+	SetKeyProvider(keys)
+	if b, _ := BlindIndex([]byte("13800000000")); a == b {
+		t.Error("blind index should depend on index key")
+	}
+}
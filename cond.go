@@ -0,0 +1,166 @@
+package xorm
+
+import (
+	"strings"
+
+	"github.com/Pius-x/xorm/utils"
+)
+
+// Column 字段名
+type Column interface {
+	Name() string
+}
+
+// Col 带类型的字段名, 一般由 cmd/xorm-cols 生成
+// 如: UserCols.Age.Gt(18)
+type Col[T any] string
+
+// Cond 条件语句片段及其参数
+type Cond struct {
+	sql  string
+	args []any
+}
+
+// Expression SQL 表达式, 作为 UpdateByMap 的值时按表达式更新 如: `gold` = `gold` * ?
+type Expression struct {
+	sql  string
+	args []any
+}
+
+// Name 字段名
+func (c Col[T]) Name() string {
+	return string(c)
+}
+
+// Eq `col` = ?
+func (c Col[T]) Eq(v T) Cond {
+	return c.compare("=", v)
+}
+
+// Ne `col` <> ?
+func (c Col[T]) Ne(v T) Cond {
+	return c.compare("<>", v)
+}
+
+// Gt `col` > ?
+func (c Col[T]) Gt(v T) Cond {
+	return c.compare(">", v)
+}
+
+// Gte `col` >= ?
+func (c Col[T]) Gte(v T) Cond {
+	return c.compare(">=", v)
+}
+
+// Lt `col` < ?
+func (c Col[T]) Lt(v T) Cond {
+	return c.compare("<", v)
+}
+
+// Lte `col` <= ?
+func (c Col[T]) Lte(v T) Cond {
+	return c.compare("<=", v)
+}
+
+// Like `col` LIKE ?
+func (c Col[T]) Like(pattern string) Cond {
+	return c.compare("LIKE", pattern)
+}
+
+// In `col` IN (?) 参数由 sqlx.In 展开
+func (c Col[T]) In(vs ...T) Cond {
+	return Cond{sql: utils.Concat(c.quote(), " IN (?)"), args: []any{vs}}
+}
+
+// NotIn `col` NOT IN (?) 参数由 sqlx.In 展开
+func (c Col[T]) NotIn(vs ...T) Cond {
+	return Cond{sql: utils.Concat(c.quote(), " NOT IN (?)"), args: []any{vs}}
+}
+
+// Between `col` BETWEEN ? AND ?
+func (c Col[T]) Between(min, max T) Cond {
+	return Cond{sql: utils.Concat(c.quote(), " BETWEEN ? AND ?"), args: []any{min, max}}
+}
+
+// IsNull `col` IS NULL
+func (c Col[T]) IsNull() Cond {
+	return Cond{sql: utils.Concat(c.quote(), " IS NULL")}
+}
+
+// IsNotNull `col` IS NOT NULL
+func (c Col[T]) IsNotNull() Cond {
+	return Cond{sql: utils.Concat(c.quote(), " IS NOT NULL")}
+}
+
+func (c Col[T]) compare(op string, v any) Cond {
+	return Cond{sql: utils.Concat(c.quote(), " ", op, " ?"), args: []any{v}}
+}
+
+func (c Col[T]) quote() string {
+	return utils.Concat("`", string(c), "`")
+}
+
+// And 使用 AND 连接多个条件
+func And(conds ...Cond) Cond {
+	return joinConds(" AND ", conds)
+}
+
+// Or 使用 OR 连接多个条件
+func Or(conds ...Cond) Cond {
+	return joinConds(" OR ", conds)
+}
+
+func joinConds(sep string, conds []Cond) Cond {
+	parts := make([]string, 0, len(conds))
+	var args []any
+	for _, cond := range conds {
+		if cond.sql == "" {
+			continue
+		}
+		parts = append(parts, utils.Concat("(", cond.sql, ")"))
+		args = append(args, cond.args...)
+	}
+	return Cond{sql: strings.Join(parts, sep), args: args}
+}
+
+// SQL 条件语句片段 (不含 WHERE)
+func (c Cond) SQL() string {
+	return c.sql
+}
+
+// Where 条件语句 可直接作为 Search Count Delete 等方法的 where 参数
+func (c Cond) Where() string {
+	if c.sql == "" {
+		return ""
+	}
+	return utils.Concat("WHERE ", c.sql)
+}
+
+// Args 条件语句的参数
+func (c Cond) Args() []any {
+	return c.args
+}
+
+// Names 获取字段名列表 可作为 SearchFields UpdateByStruct 等方法的 fields 参数
+func Names(cols ...Column) []string {
+	names := make([]string, 0, len(cols))
+	for _, col := range cols {
+		names = append(names, col.Name())
+	}
+	return names
+}
+
+// Expr 构建 SQL 表达式 如: xorm.Expr("`gold` * ?", 2)
+func Expr(sql string, args ...any) Expression {
+	return Expression{sql: sql, args: args}
+}
+
+// SQL 表达式语句
+func (e Expression) SQL() string {
+	return e.sql
+}
+
+// Args 表达式的参数
+func (e Expression) Args() []any {
+	return e.args
+}
@@ -0,0 +1,36 @@
+package xorm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Pius-x/xorm/codec"
+	"github.com/pkg/errors"
+)
+
+// RegisterConverter 注册第三方类型的转换器, 写入时使用 toDB 的返回值, 读取时使用 fromDB 转化驱动读取到的值
+// 字段为 NULL 时 fromDB 收到的 src 为 nil; 转换器优先于 Scanner/Valuer 以及复杂类型的 JSON 序列化 如:
+//
+//	xorm.RegisterConverter(
+//		func(u uuid.UUID) (any, error) { return u.String(), nil },
+//		func(src any) (uuid.UUID, error) {
+//			if src == nil {
+//				return uuid.Nil, nil
+//			}
+//			return uuid.Parse(fmt.Sprintf("%s", src))
+//		},
+//	)
+func RegisterConverter[T any](toDB func(T) (any, error), fromDB func(any) (T, error)) {
+	codec.RegisterConverter(reflect.TypeOf((*T)(nil)).Elem(), codec.Converter{
+		ToDB: func(v any) (any, error) {
+			t, ok := v.(T)
+			if !ok {
+				return nil, errors.New(fmt.Sprintf("converter expect %T but got %T", t, v))
+			}
+			return toDB(t)
+		},
+		FromDB: func(src any) (any, error) {
+			return fromDB(src)
+		},
+	})
+}
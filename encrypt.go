@@ -0,0 +1,120 @@
+package xorm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Pius-x/xorm/codec"
+	"github.com/Pius-x/xorm/utils"
+	"github.com/pkg/errors"
+)
+
+// 登记的模型 表名 => *utils.StructMeta, UpdateByMap 据此加密对应的字段
+var registeredModels sync.Map
+
+// 登记的模型中的加密字段及盲索引字段 字段名 => 表名, 用于发现写入未登记表的疑似加密字段
+var encryptedColumns sync.Map
+
+// RegisterModels 登记模型, UpdateByMap 更新这些模型的表时按模型的 encrypt 选项加密字段并更新盲索引字段
+// 含加密字段的表需在启动时登记 (包括所有分表), 通过 Search Insert UpdateByStruct 等使用过的模型也会自动登记
+// UpdateByMap 更新未登记的表时, 若字段名与已登记模型中的加密字段或盲索引字段相同则返回错误, 避免写入明文
+func RegisterModels(models ...SqlxTabler) {
+	for _, m := range models {
+		registerModel(m.TableName(), reflect.TypeOf(m))
+	}
+}
+
+// registerModel 登记模型
+// 同一张表可能对应多个模型 (如只含部分字段的模型), 以含加密字段的模型为准
+func registerModel(tb string, typ reflect.Type) {
+	if v, ok := registeredModels.Load(tb); ok && len(v.(*utils.StructMeta).Encrypted) > 0 {
+		return
+	}
+
+	meta := utils.GetStructMeta(typ, Tag)
+	if len(meta.Encrypted) == 0 {
+		registeredModels.LoadOrStore(tb, meta)
+		return
+	}
+	for col, field := range meta.Encrypted {
+		encryptedColumns.LoadOrStore(col, tb)
+		if field.Blind != "" {
+			encryptedColumns.LoadOrStore(field.Blind, tb)
+		}
+	}
+	registeredModels.Store(tb, meta)
+}
+
+// encryptMap 按登记的模型加密 Map 中的加密字段, 没有加密字段时原样返回
+// fields 为判断字段, 加密字段每次加密的密文不同, 不能作为判断字段
+func encryptMap(tb string, m map[string]any, fields []string) (map[string]any, error) {
+	v, ok := registeredModels.Load(tb)
+	if !ok {
+		for _, col := range utils.MapKeys(m, true) {
+			if other, ok := encryptedColumns.Load(col); ok {
+				return nil, errors.New(fmt.Sprintf("table %s is not registered but column %s is encrypted in %s, call RegisterModels first", tb, col, other))
+			}
+		}
+		return m, nil
+	}
+	meta := v.(*utils.StructMeta)
+
+	for _, field := range fields {
+		if _, ok = meta.Encrypted[field]; ok {
+			return nil, errors.New(fmt.Sprintf("encrypted column %s can not be used as condition, use its blind index column", field))
+		}
+	}
+
+	var encrypted map[string]any
+	for col, val := range m {
+		field, ok := meta.Encrypted[col]
+		if !ok {
+			continue
+		}
+		if _, ok = val.(Expression); ok {
+			return nil, errors.New(fmt.Sprintf("encrypted column %s can not be updated by expression", col))
+		}
+
+		if encrypted == nil {
+			encrypted = make(map[string]any, len(m)+1)
+			for k, v := range m {
+				encrypted[k] = v
+			}
+		}
+
+		if conv, ok := codec.LookupConverter(reflect.TypeOf(val)); ok {
+			var err error
+			if val, err = conv.ToDB(val); err != nil {
+				return nil, errors.WithMessage(err, col)
+			}
+		}
+		if err := utils.EncryptField(encrypted, tb, col, val, field.Options); err != nil {
+			return nil, errors.WithMessage(err, col)
+		}
+	}
+
+	if encrypted == nil {
+		return m, nil
+	}
+	return encrypted, nil
+}
+
+// BlindIndex 计算加密字段值的盲索引, 用于在盲索引字段上等值查询 如:
+//
+//	type User struct {
+//		Phone string `db:"phone,encrypt,blind=phone_bidx"`
+//	}
+//	idx, err := xorm.BlindIndex("13800000000")
+//	err = cli.Search(&users, "WHERE phone_bidx = ?", idx)
+func BlindIndex(v any) (string, error) {
+	plain, err := utils.PlainBytes(v, nil)
+	if err != nil {
+		return "", err
+	}
+	if plain == nil {
+		return "", errors.New("blind index of NULL")
+	}
+
+	return codec.BlindIndex(plain)
+}
@@ -0,0 +1,86 @@
+package xorm
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/Pius-x/xorm/codec"
+)
+
+type secretUser struct {
+	ID    int64  `db:"id"`
+	Phone string `db:"phone,encrypt,blind=phone_bidx"`
+}
+
+func (secretUser) TableName() string { return "secret_user" }
+
+// 同一张表只含部分字段的模型
+type secretUserLite struct {
+	ID int64 `db:"id"`
+}
+
+func (secretUserLite) TableName() string { return "secret_user" }
+
+func setTestKeys() {
+	codec.SetKeyProvider(codec.StaticKeys{
+		Current: "k1",
+		Keys:    map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)},
+		Index:   []byte("index"),
+	})
+}
+
+func TestUpdateByMapEncrypt(t *testing.T) {
+	setTestKeys()
+	RegisterModels(&secretUserLite{}, &secretUser{})
+
+	var args []driver.Value
+	db := &stubDB{exec: func(_ string, values []driver.Value) error {
+		args = values
+		return nil
+	}}
+	if _, err := stubCli(db).UpdateByMap("secret_user", map[string]any{"id": 1, "phone": "13800000000"}, "id"); err != nil {
+		t.Fatal(err)
+	}
+
+	query := db.Queries()[0]
+	if query != "UPDATE secret_user SET `phone` = ?,`phone_bidx` = ? WHERE true AND `id`= ?" {
+		t.Fatalf("query = %s", query)
+	}
+
+	cipher, ok := args[0].([]byte)
+	if !ok || !codec.IsEncrypted(cipher) {
+		t.Fatalf("phone = %#v, want ciphertext", args[0])
+	}
+	plain, err := codec.Decrypt(cipher, codec.FieldAAD("secret_user", "phone"))
+	if err != nil || string(plain) != "13800000000" {
+		t.Errorf("decrypt = %q, %v", plain, err)
+	}
+	if idx, _ := BlindIndex("13800000000"); args[1] != idx {
+		t.Errorf("phone_bidx = %v, want %s", args[1], idx)
+	}
+
+	// 加密字段不能作为判断字段
+	if _, err = stubCli(db).UpdateByMap("secret_user", map[string]any{"id": 1, "phone": "1"}, "phone"); err == nil {
+		t.Error("expect error for encrypted condition column")
+	}
+}
+
+func TestUpdateByMapUnregistered(t *testing.T) {
+	setTestKeys()
+	RegisterModels(&secretUser{})
+
+	db := &stubDB{}
+	_, err := stubCli(db).UpdateByMap("secret_user_1", map[string]any{"id": 1, "phone": "13800000000"}, "id")
+	if err == nil || !strings.Contains(err.Error(), "table secret_user_1 is not registered") {
+		t.Fatalf("err = %v", err)
+	}
+	if queries := db.Queries(); len(queries) != 0 {
+		t.Errorf("queries = %q, want none", queries)
+	}
+
+	if _, err = stubCli(db).UpdateByMap("other", map[string]any{"id": 1, "name": "a"}, "id"); err != nil {
+		t.Errorf("unrelated table: %v", err)
+	}
+}
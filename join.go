@@ -0,0 +1,160 @@
+package xorm
+
+import (
+	dbSql "database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/Pius-x/xorm/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/pkg/errors"
+)
+
+// 连接类型
+const (
+	innerJoin = "INNER JOIN"
+	leftJoin  = "LEFT JOIN"
+)
+
+// JoinQuery 联表查询的表及连接条件
+// 表名及查询字段由接收结果的组合结构体中 db 标签为别名的字段类型决定
+type JoinQuery struct {
+	from  string
+	joins []joinClause
+}
+
+type joinClause struct {
+	kind  string
+	alias string
+	on    string
+}
+
+type joinTable struct {
+	alias string
+	tb    string
+	tags  []string
+}
+
+// From 联表查询的主表别名
+// 如: xorm.From("u").LeftJoin("o", "o.uid = u.uid")
+func From(alias string) *JoinQuery {
+	return &JoinQuery{from: alias}
+}
+
+// Join 内连接
+func (q *JoinQuery) Join(alias string, on string) *JoinQuery {
+	q.joins = append(q.joins, joinClause{kind: innerJoin, alias: alias, on: on})
+	return q
+}
+
+// LeftJoin 左连接 (右表可能没有匹配的记录, 组合结构体中使用指针字段接收, 没有匹配时为 nil)
+func (q *JoinQuery) LeftJoin(alias string, on string) *JoinQuery {
+	q.joins = append(q.joins, joinClause{kind: leftJoin, alias: alias, on: on})
+	return q
+}
+
+// JoinSearch 联表查询, 结果映射到组合结构体中
+// dest 组合结构体指针或切片指针, 每张表对应一个 db 标签为表别名的模型字段 如:
+//
+//	type UserOrder struct {
+//		User  User   `db:"u"`
+//		Order *Order `db:"o"` // 指针字段对应的列全部为 NULL 时保持 nil
+//	}
+//
+// q 表别名及连接条件
+// where 条件语句 如: "WHERE u.age > ?" 参数放在args中
+func (cli *Cli) JoinSearch(dest any, q *JoinQuery, where string, args ...any) error {
+	if q == nil {
+		return errors.New("join query is nil")
+	}
+
+	tables, err := cli.toJoinTables(dest, q)
+	if err != nil {
+		return errors.WithMessage(err, "获取联表结构出错")
+	}
+
+	where, args, err = sqlx.In(where, args...)
+	if err != nil {
+		return errors.Wrap(err, "参数解析失败")
+	}
+
+	query, err := cli.buildJoinQuery(tables, q.joins, where)
+	if err != nil {
+		return errors.WithMessage(err, "构建联表查询语句出错")
+	}
+
+	// 过滤没有记录的正常情况
+	err = cli.joinSearch(dest, query, args...)
+	if err != nil && !errors.Is(err, dbSql.ErrNoRows) {
+		return errors.WithMessage(err, fmt.Sprintf("语句执行出错, sql:%s", query))
+	}
+
+	return err
+}
+
+// 联表查询, 指针字段对应的列全部为 NULL (LEFT JOIN 没有匹配) 时保持 nil
+func (cli *Cli) joinSearch(dest any, query string, args ...any) error {
+	r, err := cli.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	rows := cli.newRows(r)
+	rows.NullPtrs = true
+	defer rows.Close()
+
+	return cli.scanRows(dest, rows)
+}
+
+// toJoinTables 根据组合结构体解析各别名对应的表名及字段
+func (cli *Cli) toJoinTables(dest any, q *JoinQuery) ([]joinTable, error) {
+	if dest == nil {
+		return nil, errors.New("dest is nil")
+	}
+
+	typ := reflect.TypeOf(dest)
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.New("expect struct or struct slice")
+	}
+
+	aliases := make([]string, 0, len(q.joins)+1)
+	aliases = append(aliases, q.from)
+	for _, join := range q.joins {
+		aliases = append(aliases, join.alias)
+	}
+
+	tables := make([]joinTable, 0, len(aliases))
+	for _, alias := range aliases {
+		field, ok := fieldByColumn(typ, alias)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("field with tag db:\"%s\" not found in %s", alias, typ))
+		}
+
+		// 指针字段 (如 LEFT JOIN 的右表) 使用其指向的模型
+		tb, tags, err := cli.toTbAndTags(reflect.New(reflectx.Deref(field.Type)).Interface())
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("alias %s", alias))
+		}
+		tables = append(tables, joinTable{alias: alias, tb: tb, tags: tags})
+	}
+
+	return tables, nil
+}
+
+// fieldByColumn 根据 db 标签查找结构体字段
+func fieldByColumn(typ reflect.Type, col string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.IsExported() && columnName(field) == col {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// 联表查询的字段 `u`.`uid` AS `u.uid`
+func joinColumn(alias, tag string) string {
+	return utils.Concat("`", alias, "`.`", tag, "` AS `", alias, ".", tag, "`")
+}
@@ -0,0 +1,29 @@
+package xorm
+
+import (
+	"reflect"
+	"sync"
+)
+
+type queryKey struct {
+	typ  reflect.Type
+	tb   string
+	verb string
+}
+
+var queryCache sync.Map // queryKey => string
+
+// cachedQuery 按模型类型, 表名及语句类型缓存不含条件语句的 SQL, 模型的字段在运行期不变, 生成的语句可复用
+func cachedQuery(typ reflect.Type, tb string, verb string, build func() (string, error)) (string, error) {
+	key := queryKey{typ: typ, tb: tb, verb: verb}
+	if query, ok := queryCache.Load(key); ok {
+		return query.(string), nil
+	}
+
+	query, err := build()
+	if err != nil {
+		return "", err
+	}
+	queryCache.Store(key, query)
+	return query, nil
+}
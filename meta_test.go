@@ -0,0 +1,69 @@
+package xorm
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type benchRecord struct {
+	ID    int64          `db:"id"`
+	Name  string         `db:"name"`
+	Level int32          `db:"level"`
+	Gold  int64          `db:"gold"`
+	Attrs map[string]int `db:"attrs"`
+}
+
+func (benchRecord) TableName() string { return "bench" }
+
+func benchRecords(n int) []SqlxTabler {
+	records := make([]SqlxTabler, n)
+	for i := range records {
+		records[i] = &benchRecord{ID: int64(i), Name: "player", Level: 10, Gold: 100, Attrs: map[string]int{"hp": 1}}
+	}
+	return records
+}
+
+func benchCli() *Cli {
+	return &Cli{DB: sqlx.NewDb(nil, "mysql")}
+}
+
+// 按 Map 转化 10k 条记录 (Upsert 及批量更新使用)
+func BenchmarkToMapSlice10k(b *testing.B) {
+	cli, records := benchCli(), benchRecords(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := cli.toMapSlice(records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 构建 10k 条记录的插入语句及参数 (Insert 使用)
+func BenchmarkInsertQuery10k(b *testing.B) {
+	cli, records := benchCli(), benchRecords(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := cli.insertQuery(insertVerb, records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 逐行转化为 Map 再按命名参数展开 (改为按位置传参前 Insert 的写法), 作为对比
+func BenchmarkInsertNamedQuery10k(b *testing.B) {
+	cli, records := benchCli(), benchRecords(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mapSlice, tags, err := cli.toMapSlice(records)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, _, err = sqlx.Named(cli.buildInsertVerbQuery(insertVerb, "bench", tags), mapSlice); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package xorm
+
+import (
+	dbSql "database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/Pius-x/xorm/codec"
+	"github.com/Pius-x/xorm/utils"
+	"github.com/bytedance/sonic"
+	"github.com/pkg/errors"
+)
+
+// Null 可为 NULL 的字段, Valid 为 false 时写入 NULL, 读取到 NULL 时 Valid 为 false
+// T 可以是基础类型, 也可以是结构体,切片,Map等复杂类型 (使用默认编解码器序列化) 如:
+//
+//	type Player struct {
+//		Guild xorm.Null[int64]     `db:"guild"`
+//		Extra xorm.Null[ExtraInfo] `db:"extra"`
+//	}
+//
+// JSON 序列化时 Valid 为 false 输出 null
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NewNull 构造有效值
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// Ptr 有效时返回值的指针, 否则返回 nil
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
+
+// Scan 实现 sql.Scanner
+func (n *Null[T]) Scan(src any) error {
+	var zero T
+	if src == nil {
+		n.V, n.Valid = zero, false
+		return nil
+	}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if conv, ok := codec.LookupConverter(typ); ok {
+		v, err := conv.FromDB(src)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("convert %s failed", typ))
+		}
+		n.V, n.Valid = zero, true
+		if v != nil {
+			n.V = v.(T)
+		}
+		return nil
+	}
+
+	if utils.IsComplexType(typ) {
+		var data []byte
+		switch s := src.(type) {
+		case []byte:
+			data = s
+		case string:
+			data = []byte(s)
+		default:
+			return errors.New(fmt.Sprintf("can not scan %T into %s", src, typ))
+		}
+
+		n.V = zero
+		if err := utils.DecodeComplex(data, &n.V, nil); err != nil {
+			return err
+		}
+		n.Valid = true
+		return nil
+	}
+
+	var sn dbSql.Null[T]
+	if err := sn.Scan(src); err != nil {
+		return errors.WithStack(err)
+	}
+	n.V, n.Valid = sn.V, sn.Valid
+	return nil
+}
+
+// Value 实现 driver.Valuer
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if conv, ok := codec.LookupConverter(typ); ok {
+		return conv.ToDB(n.V)
+	}
+
+	if utils.IsComplexType(typ) {
+		return utils.EncodeComplex(n.V, nil)
+	}
+
+	v, err := driver.DefaultParameterConverter.ConvertValue(n.V)
+	return v, errors.WithStack(err)
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	data, err := sonic.Marshal(n.V)
+	return data, errors.WithStack(err)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	if string(data) == "null" {
+		n.V, n.Valid = zero, false
+		return nil
+	}
+
+	n.V = zero
+	if err := sonic.Unmarshal(data, &n.V); err != nil {
+		return errors.WithStack(err)
+	}
+	n.Valid = true
+	return nil
+}
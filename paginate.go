@@ -0,0 +1,156 @@
+package xorm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/Pius-x/xorm/utils"
+	"github.com/bytedance/sonic"
+	"github.com/pkg/errors"
+)
+
+// 游标解码时保留数字精度
+var cursorCodec = sonic.Config{UseNumber: true}.Froze()
+
+// Page 分页信息
+type Page struct {
+	Total int64 `json:"total"` // 总记录数
+	Page  int64 `json:"page"`  // 当前页 从1开始
+	Size  int64 `json:"size"`  // 每页记录数
+	Pages int64 `json:"pages"` // 总页数
+}
+
+// Paginate 分页查询, 返回总记录数及分页信息
+// dest 结构体切片指针
+// page 页码 从1开始; size 每页记录数
+// orderBy 排序字段, 不含 ORDER BY 如: "id DESC" "level DESC, id", 为空则不排序 (统计总数时不使用)
+// where 条件语句 如: "WHERE id > ?" 参数放在args中 (不能包含 ORDER BY LIMIT)
+// 如: cli.Paginate(&users, 2, 20, "id DESC", "WHERE age > ?", 18)
+func (cli *Cli) Paginate(dest any, page, size int64, orderBy string, where string, args ...any) (Page, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		return Page{}, errors.New("page size must be positive")
+	}
+
+	// 超出总页数时需要清空 dest, 只支持切片
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return Page{}, errors.New("expect slice pointer")
+	}
+
+	tb, _, err := cli.toTbAndTags(dest)
+	if err != nil {
+		return Page{}, errors.WithMessage(err, "获取结构体表名和Tags出错")
+	}
+
+	p := Page{Page: page, Size: size}
+	if err = cli.Count(&p.Total, tb, where, args...); err != nil {
+		return Page{}, errors.WithMessage(err, "统计分页总数出错")
+	}
+	p.Pages = (p.Total + size - 1) / size
+
+	// 超出总页数时直接返回空结果
+	if (page-1)*size >= p.Total {
+		slice.Elem().SetLen(0)
+		return p, nil
+	}
+
+	query := where
+	if orderBy != "" {
+		query = utils.Concat(query, " ORDER BY ", orderBy)
+	}
+	pageArgs := append(append(make([]any, 0, len(args)+2), args...), (page-1)*size, size)
+	if err = cli.Search(dest, utils.Concat(query, " LIMIT ?,?"), pageArgs...); err != nil {
+		return Page{}, err
+	}
+
+	return p, nil
+}
+
+// SearchAfter 游标分页查询 (keyset), 适合大表的深度翻页
+// dest 结构体切片指针
+// cursorCol 游标字段 需唯一且有索引, 默认升序, 以 - 开头表示降序 如: "id" "-id"
+// cursor 上一页返回的游标, 第一页传空字符串
+// limit 每页记录数
+// where 条件语句 如: "WHERE status = ?" 参数放在args中 (不能包含 ORDER BY LIMIT)
+// 返回下一页的游标, 没有更多数据时返回空字符串
+func (cli *Cli) SearchAfter(dest any, cursorCol string, cursor string, limit int64, where string, args ...any) (string, error) {
+	if limit < 1 {
+		return "", errors.New("limit must be positive")
+	}
+
+	col, order, op := cursorCol, "ASC", ">"
+	if strings.HasPrefix(col, "-") {
+		col, order, op = col[1:], "DESC", "<"
+	}
+
+	cond := trimWhere(where)
+	queryArgs := append(make([]any, 0, len(args)+2), args...)
+	if cursor != "" {
+		last, err := decodeCursor(cursor)
+		if err != nil {
+			return "", err
+		}
+		cond = joinCond(cond, utils.Concat("`", col, "` ", op, " ?"))
+		queryArgs = append(queryArgs, last)
+	}
+	queryArgs = append(queryArgs, limit)
+
+	query := utils.Concat(whereClause(cond), " ORDER BY `", col, "` ", order, " LIMIT ?")
+	if err := cli.Search(dest, query, queryArgs...); err != nil {
+		return "", err
+	}
+
+	rows, _ := collectStructs(dest)
+	if int64(len(rows)) < limit {
+		return "", nil
+	}
+
+	values, err := cli.columnValues(rows[len(rows)-1:], col)
+	if err != nil {
+		return "", err
+	}
+	return encodeCursor(values[0])
+}
+
+// encodeCursor 游标编码 base64(json(value))
+func encodeCursor(value any) (string, error) {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+
+	data, err := sonic.Marshal(value)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 游标解码
+func decodeCursor(cursor string) (any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cursor")
+	}
+
+	var value any
+	if err = cursorCodec.Unmarshal(data, &value); err != nil {
+		return nil, errors.Wrap(err, "invalid cursor")
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, errors.New("invalid cursor")
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	default:
+		return v, nil
+	}
+}
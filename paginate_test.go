@@ -0,0 +1,86 @@
+package xorm
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+type pageUser struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+func (pageUser) TableName() string { return "user" }
+
+// pageDB 表中有 total 条记录, 数据查询返回 rows
+func pageDB(total int64, rows ...[]driver.Value) *stubDB {
+	return &stubDB{query: func(query string, _ []driver.Value) ([]string, [][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT COUNT(1)") {
+			return []string{"COUNT(1)"}, [][]driver.Value{{total}}, nil
+		}
+		return []string{"id", "name"}, rows, nil
+	}}
+}
+
+func TestPaginate(t *testing.T) {
+	db := pageDB(3, []driver.Value{int64(3), "c"})
+	users := []pageUser{{ID: 9}}
+	p, err := stubCli(db).Paginate(&users, 2, 2, "id", "WHERE id > ?", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != (Page{Total: 3, Page: 2, Size: 2, Pages: 2}) {
+		t.Errorf("page = %+v", p)
+	}
+	if len(users) != 1 || users[0].ID != 3 {
+		t.Errorf("users = %+v", users)
+	}
+
+	queries := db.Queries()
+	if len(queries) != 2 || strings.Contains(queries[0], "ORDER BY") || !strings.Contains(queries[1], "WHERE id > ? ORDER BY id LIMIT ?,?") {
+		t.Errorf("queries = %q", queries)
+	}
+}
+
+func TestPaginateEmpty(t *testing.T) {
+	cases := []struct {
+		name  string
+		total int64
+		page  int64
+	}{
+		{"empty table", 0, 1},
+		{"past the end", 3, 5},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db := pageDB(c.total)
+			users := []pageUser{{ID: 1}, {ID: 2}}
+			p, err := stubCli(db).Paginate(&users, c.page, 2, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Total != c.total || p.Page != c.page || len(users) != 0 {
+				t.Errorf("page = %+v users = %+v", p, users)
+			}
+			if queries := db.Queries(); len(queries) != 1 {
+				t.Errorf("queries = %q, want only COUNT", queries)
+			}
+		})
+	}
+}
+
+func TestPaginateNonSlice(t *testing.T) {
+	var user pageUser
+	users := map[int64]pageUser{}
+	for _, dest := range []any{&user, &users, []pageUser{}} {
+		db := pageDB(0)
+		if _, err := stubCli(db).Paginate(dest, 1, 10, "", ""); err == nil {
+			t.Errorf("Paginate(%T) expect error", dest)
+		}
+		if queries := db.Queries(); len(queries) != 0 {
+			t.Errorf("Paginate(%T) queries = %q, want none", dest, queries)
+		}
+	}
+}
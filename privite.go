@@ -4,10 +4,11 @@ import (
 	dbSql "database/sql"
 	"fmt"
 	"reflect"
+	"strings"
 
+	"github.com/Pius-x/xorm/codec"
 	"github.com/Pius-x/xorm/sqlx_inherit"
 	"github.com/Pius-x/xorm/utils"
-	"github.com/bytedance/sonic"
 	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/types"
 	"github.com/pkg/errors"
@@ -50,47 +51,129 @@ func (cli *Cli) toTbAndTags(record any) (string, []string, error) {
 	}
 
 	val, typ := utils.Indirect(record)
-	if val.Kind() == reflect.Slice {
+	switch val.Kind() {
+	case reflect.Slice:
+		typ = typ.Elem()
+	case reflect.Map:
+		// map[K]T 或 map[K][]T
+		if typ = typ.Elem(); typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+		}
+	}
+	for typ.Kind() == reflect.Ptr {
 		typ = typ.Elem()
-		val = reflect.Zero(typ)
+	}
+	if typ.Kind() != reflect.Struct {
+		return "", nil, errors.New("expect struct")
 	}
 
-	st, ok := val.Interface().(SqlxTabler)
+	// 单条记录使用记录本身的表名 (可能按记录分表), 切片及 Map 使用元素类型的表名
+	st, ok := record.(SqlxTabler)
 	if !ok {
-		return "", nil, errors.New("slice elem expect SqlxTabler")
+		st, ok = reflect.New(typ).Interface().(SqlxTabler)
 	}
-
-	smap := make(map[string]any)
-	if err := utils.ReflectToMap(smap, val, Tag, false); err != nil {
-		return "", nil, err
+	if !ok {
+		return "", nil, errors.New("slice elem expect SqlxTabler")
 	}
 
-	return st.TableName(), utils.MapKeys(smap, false), nil
+	tb := st.TableName()
+	registerModel(tb, typ)
+	return tb, utils.GetStructMeta(typ, Tag).Columns, nil
 }
 
+// 插入语句的写入方式
+const (
+	insertVerb       = "INSERT INTO"
+	insertIgnoreVerb = "INSERT IGNORE INTO"
+	replaceVerb      = "REPLACE INTO"
+)
+
 func (cli *Cli) insert(records []SqlxTabler) (dbSql.Result, error) {
-	mapSlice, tags, err := cli.toMapSlice(records)
+	return cli.insertWith(insertVerb, records)
+}
+
+func (cli *Cli) insertWith(verb string, records []SqlxTabler) (dbSql.Result, error) {
+	query, args, err := cli.insertQuery(verb, records)
 	if err != nil {
 		return nil, err
 	}
 
-	query := cli.buildInsetQuery(records[0].TableName(), tags)
-
-	result, err := cli.NamedExec(query, mapSlice)
+	result, err := cli.Exec(query, args...)
 	if err != nil {
-		return nil, errors.WithMessage(err, "Insert 语句执行出错")
+		return nil, errors.WithMessage(err, fmt.Sprintf("%s 语句执行出错", verb))
 	}
 
 	return result, nil
 }
 
-func (cli *Cli) upsert(records []SqlxTabler) (dbSql.Result, error) {
+// insertQuery 构建批量插入语句及参数, 参数按行依次排列, 不为每行创建 Map
+func (cli *Cli) insertQuery(verb string, records []SqlxTabler) (string, []any, error) {
+	typ, err := recordsType(records)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tb := records[0].TableName()
+	registerModel(tb, typ)
+	tags := utils.GetStructMeta(typ, Tag).WriteColumns
+
+	// 单行的语句按类型缓存, 多行时重复 VALUES 部分
+	row, err := cachedQuery(typ, tb, verb, func() (string, error) {
+		return cli.buildInsertValuesQuery(verb, tb, tags, 1)
+	})
+	if err != nil {
+		return "", nil, errors.WithMessage(err, "构建插入语句出错")
+	}
+	query := row
+	if len(records) > 1 {
+		values := utils.Concat("(", strings.Repeat("?,", len(tags)-1), "?)")
+		var builder strings.Builder
+		builder.Grow(len(row) + (len(values)+1)*(len(records)-1))
+		builder.WriteString(row)
+		for i := 1; i < len(records); i++ {
+			builder.WriteByte(',')
+			builder.WriteString(values)
+		}
+		query = builder.String()
+	}
+
+	args := make([]any, 0, len(tags)*len(records))
+	for _, record := range records {
+		if args, err = utils.AppendStructValues(args, reflect.ValueOf(record), Tag); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return cli.Rebind(query), args, nil
+}
+
+// recordsType 批量写入的记录需为同一类型, 表名及字段均由第一条记录决定
+func recordsType(records []SqlxTabler) (reflect.Type, error) {
+	if len(records) == 0 {
+		return nil, errors.New("records is empty")
+	}
+
+	typ := reflect.TypeOf(records[0])
+	for _, record := range records[1:] {
+		if t := reflect.TypeOf(record); t != typ {
+			return nil, errors.New(fmt.Sprintf("records expect same type, got %s and %s", typ, t))
+		}
+	}
+	return typ, nil
+}
+
+func (cli *Cli) upsert(records []SqlxTabler, opts []UpsertOption) (dbSql.Result, error) {
 	mapSlice, tags, err := cli.toMapSlice(records)
 	if err != nil {
 		return nil, err
 	}
 
-	query := cli.buildUpsertQuery(records[0].TableName(), tags)
+	options := &upsertOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	query := cli.buildUpsertQuery(records[0].TableName(), tags, options)
 
 	result, err := cli.NamedExec(query, mapSlice)
 	if err != nil {
@@ -100,9 +183,10 @@ func (cli *Cli) upsert(records []SqlxTabler) (dbSql.Result, error) {
 	return result, nil
 }
 
+// toMapSlice 记录转化为 Map 切片, 以及写入的字段名 (按第一条记录的类型)
 func (cli *Cli) toMapSlice(records []SqlxTabler) ([]map[string]any, []string, error) {
-	if len(records) == 0 {
-		return nil, nil, errors.New("Update records empty")
+	if _, err := recordsType(records); err != nil {
+		return nil, nil, err
 	}
 
 	mmp := make([]map[string]any, 0, len(records))
@@ -114,14 +198,66 @@ func (cli *Cli) toMapSlice(records []SqlxTabler) ([]map[string]any, []string, er
 		mmp = append(mmp, smp)
 	}
 
-	return mmp, utils.MapKeys(mmp[0], false), nil
+	typ := reflect.TypeOf(records[0])
+	registerModel(records[0].TableName(), typ)
+	return mmp, utils.GetStructMeta(typ, Tag).WriteColumns, nil
+}
+
+// filterUpdateMap 根据 Cols Omit NonZeroOnly 过滤需要更新的字段, 判断字段始终保留
+func (cli *Cli) filterUpdateMap(record SqlxTabler, updateMap map[string]any, fields []string) (map[string]any, error) {
+	if len(cli.cols) == 0 && len(cli.omits) == 0 && !cli.nonZero {
+		return updateMap, nil
+	}
+
+	// 零值判断使用未序列化的原始值
+	var rawMap map[string]any
+	if cli.nonZero {
+		var err error
+		if rawMap, err = utils.StructToMap(record, Tag, false); err != nil {
+			return nil, errors.WithMessage(err, "转化成Map出错")
+		}
+	}
+
+	// 盲索引字段跟随其加密字段
+	blinds := utils.BlindColumns(reflect.TypeOf(record), Tag)
+
+	filtered := make(map[string]any, len(updateMap))
+	for tag, val := range updateMap {
+		if !utils.InSlice(tag, fields) {
+			col := tag
+			if src, ok := blinds[tag]; ok {
+				col = src
+			}
+			if len(cli.cols) > 0 && !utils.InSlice(col, cli.cols) {
+				continue
+			}
+			if utils.InSlice(col, cli.omits) {
+				continue
+			}
+			if cli.nonZero && (rawMap[col] == nil || reflect.ValueOf(rawMap[col]).IsZero()) {
+				continue
+			}
+		}
+		filtered[tag] = val
+	}
+
+	return filtered, nil
 }
 
 func (cli *Cli) stringifyMap(m map[string]any) (map[string]any, error) {
 	hm := make(map[string]any, len(m))
 	for k, v := range m {
-		if utils.IsComplexType(reflect.TypeOf(v)) {
-			marshal, err := sonic.MarshalString(v)
+		if conv, ok := codec.LookupConverter(reflect.TypeOf(v)); ok {
+			converted, err := conv.ToDB(v)
+			if err != nil {
+				return nil, errors.WithMessage(err, k)
+			}
+			hm[k] = converted
+			continue
+		}
+
+		if _, ok := v.(Expression); !ok && utils.IsComplexType(reflect.TypeOf(v)) {
+			marshal, err := utils.EncodeComplex(v, nil)
 			if err != nil {
 				return nil, errors.WithStack(err)
 			}
@@ -172,15 +308,61 @@ func (cli *Cli) search(dest any, query string, args ...any) error {
 	if err != nil {
 		return err
 	}
-	rows := &sqlx_inherit.Rows{Rows: r, Mapper: cli.Mapper}
+	rows := cli.newRows(r)
 	defer rows.Close()
 
+	return cli.scanRows(dest, rows)
+}
+
+// 扫描到结构体或切片中
+func (cli *Cli) scanRows(dest any, rows *sqlx_inherit.Rows) error {
 	if cli.isSearchSlice(dest) {
 		return sqlx_inherit.ScanAll(rows, dest, false)
 	}
 	return sqlx_inherit.ScanAny(rows, dest, false)
 }
 
+// 查询到以键字段为键的 Map 中
+func (cli *Cli) keyedSearch(dest any, query string, args ...any) error {
+	r, err := cli.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	rows := cli.newRows(r)
+	defer rows.Close()
+
+	keyBy := cli.keyBy
+	if keyBy == "" {
+		keyBy = pkColumn(keyedElemType(dest))
+	}
+	return sqlx_inherit.ScanKeyed(rows, dest, keyBy)
+}
+
+// isKeyedDest 是否查询到 map[K]T 或 map[K][]T (T 为结构体或结构体指针)
+func (cli *Cli) isKeyedDest(dest any) bool {
+	typ := keyedElemType(dest)
+	return typ != nil && typ.Kind() == reflect.Struct
+}
+
+// keyedElemType 获取 map[K]T 或 map[K][]T 中的结构体类型, 不是 Map 时返回 nil
+func keyedElemType(dest any) reflect.Type {
+	typ := reflect.TypeOf(dest)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Map {
+		return nil
+	}
+
+	if typ = typ.Elem(); typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
 // map查询封装
 func (cli *Cli) mapSearch(dest any, query string, args ...any) error {
 	rows, err := cli.Queryx(query, args...)
@@ -190,9 +372,93 @@ func (cli *Cli) mapSearch(dest any, query string, args ...any) error {
 	defer rows.Close()
 
 	if cli.isSearchSlice(dest) {
-		return sqlx_inherit.ScanMap(rows, dest)
+		return sqlx_inherit.ScanMap(rows, dest, cli.hints)
 	}
-	return sqlx_inherit.ScanMapOnce(rows, dest)
+	return sqlx_inherit.ScanMapOnce(rows, dest, cli.hints)
+}
+
+// 原子增减封装 op 为 + 或 -
+func (cli *Cli) incr(tb string, values map[string]any, op string, floor bool, where string, args []any) (dbSql.Result, error) {
+	if len(values) == 0 {
+		return nil, errors.New("incr map is empty")
+	}
+
+	where, args, err := sqlx.In(where, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "参数解析失败")
+	}
+
+	fields := utils.MapKeys(values, true)
+	queryArgs := make([]any, 0, len(fields)*2+len(args))
+	for _, field := range fields {
+		queryArgs = append(queryArgs, values[field])
+	}
+	queryArgs = append(queryArgs, args...)
+
+	// 下限保护 减少后不小于0
+	if floor {
+		cond := trimWhere(where)
+		for _, field := range fields {
+			cond = joinCond(cond, utils.Concat("`", field, "` >= ?"))
+			queryArgs = append(queryArgs, values[field])
+		}
+		where = whereClause(cond)
+	}
+
+	query, err := cli.buildIncrQuery(tb, fields, op, where)
+	if err != nil {
+		return nil, errors.WithMessage(err, "构建增减语句出错")
+	}
+
+	result, err := cli.Exec(query, queryArgs...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "incr 语句执行出错")
+	}
+
+	return result, nil
+}
+
+// 聚合查询封装
+func (cli *Cli) aggregate(dest any, fn string, tb string, field string, where string, args []any) (err error) {
+
+	where, args, err = sqlx.In(where, args...)
+	if err != nil {
+		return errors.Wrap(err, "参数解析失败")
+	}
+
+	query := cli.buildAggregateQuery(fn, tb, field, where)
+
+	return cli.Get(dest, query, args...)
+}
+
+// 分组查询结果写入Map 每行第一列为键 第二列为值
+func (cli *Cli) groupMapSearch(dest any, query string, args ...any) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("must pass a non-nil map pointer")
+	}
+
+	direct := value.Elem()
+	if direct.IsNil() {
+		direct.Set(reflect.MakeMap(direct.Type()))
+	}
+
+	rows, err := cli.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keyType, valType := direct.Type().Key(), direct.Type().Elem()
+	for rows.Next() {
+		key, val := reflect.New(keyType), reflect.New(valType)
+		if err = rows.Scan(key.Interface(), val.Interface()); err != nil {
+			return errors.WithStack(err)
+		}
+		direct.SetMapIndex(key.Elem(), val.Elem())
+	}
+
+	return errors.WithStack(rows.Err())
 }
 
 // 查询指定字段
@@ -246,3 +512,28 @@ func (cli *Cli) isSearchSlice(dest any) bool {
 	}
 	return false
 }
+
+// trimWhere 去掉条件语句开头的 WHERE
+func trimWhere(where string) string {
+	where = strings.TrimSpace(where)
+	if len(where) >= 5 && strings.EqualFold(where[:5], "WHERE") {
+		where = strings.TrimSpace(where[5:])
+	}
+	return where
+}
+
+// joinCond 使用 AND 追加条件
+func joinCond(cond string, extra string) string {
+	if cond == "" {
+		return extra
+	}
+	return utils.Concat("(", cond, ") AND ", extra)
+}
+
+// whereClause 为条件添加 WHERE
+func whereClause(cond string) string {
+	if cond == "" {
+		return ""
+	}
+	return utils.Concat("WHERE ", cond)
+}
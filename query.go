@@ -0,0 +1,78 @@
+package xorm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Pius-x/xorm/sqlx_inherit"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// Tuple2 两列查询结果
+type Tuple2[A, B any] struct {
+	V1 A
+	V2 B
+}
+
+// QueryAll 执行任意查询并扫描到 T 的切片中, T 可以是不实现 SqlxTabler 的结构体 (包括匿名结构体) 或基础类型
+// 复杂字段的解析与 Search 一致 如:
+//
+//	rows, err := xorm.QueryAll[struct {
+//		Day   string `db:"day"`
+//		Total int64  `db:"total"`
+//	}](cli, "SELECT DATE(created_at) AS day, COUNT(1) AS total FROM orders GROUP BY day")
+func QueryAll[T any](cli *Cli, query string, args ...any) ([]T, error) {
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "参数解析失败")
+	}
+
+	r, err := cli.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	rows := cli.newRows(r)
+	defer rows.Close()
+
+	var result []T
+	if err = sqlx_inherit.ScanAll(rows, &result, false); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("语句执行出错, sql:%s", query))
+	}
+	return result, nil
+}
+
+// QueryTuple2 执行两列的查询, 按列顺序扫描到 Tuple2 中 如:
+//
+//	pairs, err := xorm.QueryTuple2[int64, string](cli, "SELECT id, name FROM user WHERE level > ?", 10)
+func QueryTuple2[A, B any](cli *Cli, query string, args ...any) ([]Tuple2[A, B], error) {
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "参数解析失败")
+	}
+
+	rows, err := cli.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(columns) != 2 {
+		return nil, errors.New(fmt.Sprintf("tuple2 expect 2 columns but got %d", len(columns)))
+	}
+
+	var result []Tuple2[A, B]
+	for rows.Next() {
+		var t Tuple2[A, B]
+		if err = sqlx_inherit.ScanRow(rows, reflect.ValueOf(&t.V1).Elem(), reflect.ValueOf(&t.V2).Elem()); err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("语句执行出错, sql:%s", query))
+		}
+		result = append(result, t)
+	}
+
+	return result, errors.WithStack(rows.Err())
+}
@@ -0,0 +1,410 @@
+package xorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Pius-x/xorm/utils"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/pkg/errors"
+)
+
+// 关联类型
+const (
+	relHasOne     = "hasone"    // xorm:"hasone:子表外键"
+	relHasMany    = "hasmany"   // xorm:"hasmany:子表外键"
+	relBelongsTo  = "belongsto" // xorm:"belongsto:本表外键"
+	relManyToMany = "many2many" // xorm:"many2many:中间表:中间表本表外键:中间表子表外键"
+)
+
+type preload struct {
+	path  string
+	where string
+	args  []any
+	err   error // 参数错误, 在 Search 时返回
+}
+
+type relation struct {
+	kind       string
+	field      reflect.StructField
+	elem       reflect.Type // 子表结构体类型
+	foreignKey string
+	joinTable  string
+	joinFk     string
+	joinRefFk  string
+}
+
+// Preload 查询时预加载关联字段, 每个关联只执行一次 IN 查询 (仅对 Search 生效)
+// path 关联字段名, 嵌套关联使用 . 分隔 如: "Items" "Items.Attrs"
+// where 可选的子表附加条件, 不含 WHERE 如: cli.Preload("Items", "count > ?", 0)
+func (cli *Cli) Preload(path string, where ...any) *Cli {
+	p := preload{path: path}
+	if len(where) > 0 {
+		if cond, ok := where[0].(string); ok {
+			p.where, p.args = cond, where[1:]
+		} else {
+			p.err = errors.New(fmt.Sprintf("Preload(%q) condition must be a string, got %T", path, where[0]))
+		}
+	}
+
+	s := cli.session()
+	s.preloads = append(append(make([]preload, 0, len(cli.preloads)+1), cli.preloads...), p)
+	return s
+}
+
+// checkPreloads 检查 Preload 的参数
+func (cli *Cli) checkPreloads() error {
+	for _, p := range cli.preloads {
+		if p.err != nil {
+			return p.err
+		}
+	}
+	return nil
+}
+
+// loadRelations 为查询结果加载预加载的关联字段
+func (cli *Cli) loadRelations(dest any) error {
+	parents, typ := collectStructs(dest)
+	if len(parents) == 0 {
+		return nil
+	}
+
+	// 按第一级字段名分组, 剩余路径交给子查询继续加载
+	var names []string
+	groups := make(map[string][]preload)
+	for _, p := range cli.preloads {
+		name, rest, nested := strings.Cut(p.path, ".")
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+			groups[name] = nil
+		}
+		if nested {
+			groups[name] = append(groups[name], preload{path: rest, where: p.where, args: p.args})
+		} else {
+			groups[name] = append(groups[name], preload{where: p.where, args: p.args})
+		}
+	}
+
+	for _, name := range names {
+		rel, err := parseRelation(typ, name)
+		if err != nil {
+			return err
+		}
+
+		sub := cli.session()
+		sub.preloads = nil
+		var where string
+		var args []any
+		for _, p := range groups[name] {
+			if p.path != "" {
+				sub.preloads = append(sub.preloads, p)
+			} else if p.where != "" {
+				where, args = p.where, p.args
+			}
+		}
+
+		if err = sub.loadRelation(parents, rel, where, args); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("预加载 %s 出错", name))
+		}
+	}
+
+	return nil
+}
+
+// loadRelation 加载单个关联字段并赋值到父结构体
+func (cli *Cli) loadRelation(parents []reflect.Value, rel *relation, where string, args []any) error {
+	parentType := parents[0].Type()
+
+	var parentCol, childCol string
+	switch rel.kind {
+	case relHasOne, relHasMany:
+		parentCol, childCol = pkColumn(parentType), rel.foreignKey
+	case relBelongsTo:
+		parentCol, childCol = rel.foreignKey, pkColumn(rel.elem)
+	case relManyToMany:
+		parentCol, childCol = pkColumn(parentType), pkColumn(rel.elem)
+	}
+
+	parentKeys, err := cli.columnValues(parents, parentCol)
+	if err != nil {
+		return err
+	}
+	keys := uniqueKeys(parentKeys)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// 多对多 先查询中间表得到 父表主键 -> 子表主键 的对应关系
+	var links map[string][]string
+	if rel.kind == relManyToMany {
+		if links, keys, err = cli.loadJoinTable(rel, keys); err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+	}
+
+	childWhere := utils.Concat("WHERE `", childCol, "` IN (?)")
+	childArgs := append([]any{keys}, args...)
+	if where != "" {
+		childWhere = utils.Concat(childWhere, " AND (", where, ")")
+	}
+
+	children := reflect.New(reflect.SliceOf(rel.elem))
+	if err = cli.Search(children.Interface(), childWhere, childArgs...); err != nil {
+		return err
+	}
+
+	childList := make([]reflect.Value, 0, children.Elem().Len())
+	for i := 0; i < children.Elem().Len(); i++ {
+		childList = append(childList, children.Elem().Index(i))
+	}
+	childKeys, err := cli.columnValues(childList, childCol)
+	if err != nil {
+		return err
+	}
+
+	grouped := make(map[string][]reflect.Value, len(childList))
+	for i, child := range childList {
+		k := keyString(childKeys[i])
+		grouped[k] = append(grouped[k], child)
+	}
+
+	for i, parent := range parents {
+		k := keyString(parentKeys[i])
+
+		var matched []reflect.Value
+		if rel.kind == relManyToMany {
+			for _, childKey := range links[k] {
+				matched = append(matched, grouped[childKey]...)
+			}
+		} else {
+			matched = grouped[k]
+		}
+
+		assignRelation(parent.FieldByIndex(rel.field.Index), matched)
+	}
+
+	return nil
+}
+
+// loadJoinTable 查询多对多的中间表
+func (cli *Cli) loadJoinTable(rel *relation, keys []any) (map[string][]string, []any, error) {
+	var rows []map[string]any
+	where := utils.Concat("WHERE `", rel.joinFk, "` IN (?)")
+	if err := cli.SearchFields(&rows, rel.joinTable, []string{rel.joinFk, rel.joinRefFk}, where, keys); err != nil {
+		return nil, nil, err
+	}
+
+	links := make(map[string][]string, len(keys))
+	childKeys := make([]any, 0, len(rows))
+	for _, row := range rows {
+		parentKey, childKey := keyString(row[rel.joinFk]), keyString(row[rel.joinRefFk])
+		links[parentKey] = append(links[parentKey], childKey)
+		childKeys = append(childKeys, row[rel.joinRefFk])
+	}
+
+	return links, uniqueKeys(childKeys), nil
+}
+
+// columnValues 读取结构体列表中指定字段的值
+func (cli *Cli) columnValues(structs []reflect.Value, col string) ([]any, error) {
+	if len(structs) == 0 {
+		return nil, nil
+	}
+
+	fi := cli.Mapper.TypeMap(structs[0].Type()).GetByPath(col)
+	if fi == nil {
+		return nil, errors.New(fmt.Sprintf("column %s not found in %s", col, structs[0].Type()))
+	}
+
+	values := make([]any, 0, len(structs))
+	for _, v := range structs {
+		values = append(values, reflectx.FieldByIndexesReadOnly(v, fi.Index).Interface())
+	}
+	return values, nil
+}
+
+// parseRelation 解析关联字段的 xorm 标签
+func parseRelation(typ reflect.Type, name string) (*relation, error) {
+	field, ok := typ.FieldByName(name)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("relation field %s not found in %s", name, typ))
+	}
+
+	rel := &relation{field: field, elem: field.Type}
+	for rel.elem.Kind() == reflect.Ptr || rel.elem.Kind() == reflect.Slice {
+		rel.elem = rel.elem.Elem()
+	}
+	if rel.elem.Kind() != reflect.Struct {
+		return nil, errors.New(fmt.Sprintf("relation field %s expect struct or struct slice", name))
+	}
+
+	opts := parseXormTag(field.Tag.Get(XormTag))
+	for _, kind := range []string{relHasOne, relHasMany, relBelongsTo, relManyToMany} {
+		val, ok := opts[kind]
+		if !ok {
+			continue
+		}
+		rel.kind = kind
+
+		if kind == relManyToMany {
+			parts := strings.Split(val, ":")
+			if len(parts) != 3 {
+				return nil, errors.New(fmt.Sprintf("relation field %s expect many2many:table:fk:ref_fk", name))
+			}
+			rel.joinTable, rel.joinFk, rel.joinRefFk = parts[0], parts[1], parts[2]
+		} else {
+			rel.foreignKey = val
+		}
+	}
+
+	if rel.kind == "" {
+		return nil, errors.New(fmt.Sprintf("field %s has no relation tag", name))
+	}
+	if rel.kind != relManyToMany && rel.foreignKey == "" {
+		return nil, errors.New(fmt.Sprintf("relation field %s missing foreign key", name))
+	}
+	isSlice := field.Type.Kind() == reflect.Slice
+	if (rel.kind == relHasMany || rel.kind == relManyToMany) != isSlice {
+		return nil, errors.New(fmt.Sprintf("relation field %s type %s does not match %s", name, field.Type, rel.kind))
+	}
+
+	return rel, nil
+}
+
+// assignRelation 将子表记录赋值到关联字段, 支持 T *T []T []*T
+func assignRelation(field reflect.Value, children []reflect.Value) {
+	typ := field.Type()
+
+	if typ.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(typ, 0, len(children))
+		for _, child := range children {
+			if typ.Elem().Kind() == reflect.Ptr {
+				slice = reflect.Append(slice, child.Addr())
+			} else {
+				slice = reflect.Append(slice, child)
+			}
+		}
+		field.Set(slice)
+		return
+	}
+
+	if len(children) == 0 {
+		field.Set(reflect.Zero(typ))
+		return
+	}
+
+	if typ.Kind() == reflect.Ptr {
+		field.Set(children[0].Addr())
+	} else {
+		field.Set(children[0])
+	}
+}
+
+// collectStructs 收集查询结果中的结构体 (可寻址), 支持 *T *[]T *[]*T
+func collectStructs(dest any) ([]reflect.Value, reflect.Type) {
+	val := reflect.Indirect(reflect.ValueOf(dest))
+
+	var structs []reflect.Value
+	switch val.Kind() {
+	case reflect.Struct:
+		structs = append(structs, val)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			structs = appendStruct(structs, val.Index(i))
+		}
+	case reflect.Map:
+		// map[K]T 的值不可寻址, 仅收集 map[K]*T map[K][]T map[K][]*T 中的结构体, map[K]T 需先经 addressable 转化
+		iter := val.MapRange()
+		for iter.Next() {
+			elem := iter.Value()
+			if elem.Kind() == reflect.Slice {
+				for i := 0; i < elem.Len(); i++ {
+					structs = appendStruct(structs, elem.Index(i))
+				}
+			} else if elem.Kind() == reflect.Ptr {
+				structs = appendStruct(structs, elem)
+			}
+		}
+	}
+
+	if len(structs) == 0 {
+		return nil, nil
+	}
+	return structs, structs[0].Type()
+}
+
+// addressable map[K]T 的值不可寻址, 复制为 map[K]*T 供预加载及保存快照使用, writeBack 将修改写回原 Map
+// 其余类型原样返回
+func addressable(dest any) (any, func()) {
+	val := reflect.Indirect(reflect.ValueOf(dest))
+	if val.Kind() != reflect.Map || val.Type().Elem().Kind() != reflect.Struct {
+		return dest, func() {}
+	}
+
+	elemType := val.Type().Elem()
+	ptrs := reflect.MakeMapWithSize(reflect.MapOf(val.Type().Key(), reflect.PtrTo(elemType)), val.Len())
+	iter := val.MapRange()
+	for iter.Next() {
+		p := reflect.New(elemType)
+		p.Elem().Set(iter.Value())
+		ptrs.SetMapIndex(iter.Key(), p)
+	}
+
+	return ptrs.Interface(), func() {
+		iter := ptrs.MapRange()
+		for iter.Next() {
+			val.SetMapIndex(iter.Key(), iter.Value().Elem())
+		}
+	}
+}
+
+func appendStruct(structs []reflect.Value, elem reflect.Value) []reflect.Value {
+	if elem.Kind() == reflect.Ptr {
+		if elem.IsNil() {
+			return structs
+		}
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.Struct {
+		structs = append(structs, elem)
+	}
+	return structs
+}
+
+// uniqueKeys 去除重复及空值的关联键
+func uniqueKeys(values []any) []any {
+	seen := make(map[string]bool, len(values))
+	keys := make([]any, 0, len(values))
+	for _, v := range values {
+		k := keyString(v)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, v)
+	}
+	return keys
+}
+
+// keyString 关联键统一转化为字符串用于匹配 (兼容驱动返回的 []byte)
+func keyString(v any) string {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return ""
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return ""
+	}
+
+	if b, ok := val.Interface().([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(val.Interface())
+}
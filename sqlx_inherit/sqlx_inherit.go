@@ -4,11 +4,12 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/Pius-x/xorm/codec"
 	"github.com/Pius-x/xorm/utils"
-	"github.com/bytedance/sonic"
 	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/reflectx"
 	"github.com/pkg/errors"
@@ -17,6 +18,13 @@ import (
 type Rows struct {
 	*sql.Rows
 	Mapper *reflectx.Mapper
+
+	// Lenient 为 true 时跳过结构体中没有对应字段的列, 而不是返回 missing destination name 错误
+	Lenient bool
+	// OnUnknown 宽松模式下报告被跳过的列, 每次扫描最多调用一次
+	OnUnknown func(dest reflect.Type, columns []string)
+	// NullPtrs 为 true 时指针结构体字段对应的列全部为 NULL 则该字段保持 nil (如 LEFT JOIN 没有匹配的右表)
+	NullPtrs bool
 }
 
 var (
@@ -59,6 +67,14 @@ func ScanAny(r *Rows, dest interface{}, structOnly bool) error {
 	}
 
 	if scannable {
+		if conv, ok := codec.LookupConverter(base); ok {
+			src := new(any)
+			if err = scan(r, src); err != nil {
+				return err
+			}
+			return convertField(v.Elem(), conv, *src)
+		}
+
 		if !utils.IsComplexType(v.Elem().Type()) {
 			return scan(r, dest)
 		}
@@ -68,7 +84,7 @@ func ScanAny(r *Rows, dest interface{}, structOnly bool) error {
 			return err
 		}
 
-		if err = sonic.Unmarshal(*values, v.Interface()); err != nil {
+		if err = utils.DecodeComplex(*values, v.Interface(), nil); err != nil {
 			return errors.Wrap(err, "check if the struct matches\n")
 		}
 
@@ -78,12 +94,13 @@ func ScanAny(r *Rows, dest interface{}, structOnly bool) error {
 	fields := r.Mapper.TraversalsByName(v.Type(), columns)
 
 	// if we are not unsafe and are missing fields, return an error
-	if f, err := missingFields(fields); err != nil {
+	if f, err := missingFields(fields); err != nil && !r.skipMissing(base, columns, fields) {
 		return fmt.Errorf("missing destination name %s in %T", columns[f], dest)
 	}
 
+	metas := columnMetas(r.Mapper, base, fields, r.NullPtrs)
 	values := make([]interface{}, len(columns))
-	if err = fieldsByTraversal(v, fields, values); err != nil {
+	if err = fieldsByTraversal(v, fields, values, metas); err != nil {
 		return err
 	}
 
@@ -93,7 +110,7 @@ func ScanAny(r *Rows, dest interface{}, structOnly bool) error {
 	}
 
 	// 解析复杂数据格式
-	if err = parseComplexField(v.Elem(), fields, values); err != nil {
+	if err = parseComplexField(v.Elem(), fields, values, metas); err != nil {
 		return err
 	}
 
@@ -143,9 +160,10 @@ func ScanAll(rows *Rows, dest interface{}, structOnly bool) error {
 
 		fields := rows.Mapper.TraversalsByName(base, columns)
 		// if we are not unsafe and are missing fields, return an error
-		if f, err := missingFields(fields); err != nil {
+		if f, err := missingFields(fields); err != nil && !rows.skipMissing(base, columns, fields) {
 			return errors.WithStack(fmt.Errorf("missing destination name %s in %T", columns[f], dest))
 		}
+		metas := columnMetas(rows.Mapper, base, fields, rows.NullPtrs)
 		values = make([]interface{}, len(columns))
 
 		for rows.Next() {
@@ -153,7 +171,7 @@ func ScanAll(rows *Rows, dest interface{}, structOnly bool) error {
 			vp = reflect.New(base)
 			v = reflect.Indirect(vp)
 
-			if err = fieldsByTraversal(v, fields, values); err != nil {
+			if err = fieldsByTraversal(v, fields, values, metas); err != nil {
 				return err
 			}
 
@@ -163,7 +181,7 @@ func ScanAll(rows *Rows, dest interface{}, structOnly bool) error {
 			}
 
 			// 解析复杂数据格式
-			if err = parseComplexField(v, fields, values); err != nil {
+			if err = parseComplexField(v, fields, values, metas); err != nil {
 				return err
 			}
 
@@ -175,11 +193,14 @@ func ScanAll(rows *Rows, dest interface{}, structOnly bool) error {
 		}
 	} else {
 		var values any
+		conv, hasConv := codec.LookupConverter(base)
 
 		for rows.Next() {
 			vp = reflect.New(base)
 			v = reflect.Indirect(vp)
-			if utils.IsComplexType(v.Type()) {
+			if hasConv {
+				values = new(any)
+			} else if utils.IsComplexType(v.Type()) {
 				values = new([]byte)
 			} else {
 				values = vp.Interface()
@@ -189,8 +210,12 @@ func ScanAll(rows *Rows, dest interface{}, structOnly bool) error {
 				return errors.WithStack(err)
 			}
 
-			if utils.IsComplexType(v.Type()) {
-				if err = sonic.Unmarshal(*values.(*[]byte), v.Addr().Interface()); err != nil {
+			if hasConv {
+				if err = convertField(v, conv, *values.(*any)); err != nil {
+					return err
+				}
+			} else if utils.IsComplexType(v.Type()) {
+				if err = utils.DecodeComplex(*values.(*[]byte), v.Addr().Interface(), nil); err != nil {
 					return errors.Wrap(err, "check if the struct matches")
 				}
 			}
@@ -207,7 +232,187 @@ func ScanAll(rows *Rows, dest interface{}, structOnly bool) error {
 	return errors.WithStack(rows.Err())
 }
 
-func ScanMap(rows *sqlx.Rows, dest any) error {
+// ScanEach 逐行扫描到 base 类型的结构体中并回调, 不保留整个结果集, 适用于流式导出
+func ScanEach(rows *Rows, base reflect.Type, fn func(v reflect.Value) error) error {
+	if base.Kind() != reflect.Struct {
+		return errors.New(fmt.Sprintf("expected struct but got %s", base.Kind()))
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	fields := rows.Mapper.TraversalsByName(base, columns)
+	if f, err := missingFields(fields); err != nil && !rows.skipMissing(base, columns, fields) {
+		return errors.WithStack(fmt.Errorf("missing destination name %s in %s", columns[f], base))
+	}
+	metas := columnMetas(rows.Mapper, base, fields, rows.NullPtrs)
+	values := make([]interface{}, len(columns))
+
+	for rows.Next() {
+		v := reflect.New(base).Elem()
+
+		if err = fieldsByTraversal(v, fields, values, metas); err != nil {
+			return err
+		}
+
+		if err = rows.Scan(values...); err != nil {
+			return errors.WithStack(err)
+		}
+
+		// 解析复杂数据格式
+		if err = parseComplexField(v, fields, values, metas); err != nil {
+			return err
+		}
+
+		if err = fn(v); err != nil {
+			return err
+		}
+	}
+
+	return errors.WithStack(rows.Err())
+}
+
+// ScanRow 将当前行按列顺序扫描到 dest 中 (dest 为可寻址的值), 复杂数据及注册了转换器的类型与结构体字段的处理方式一致
+func ScanRow(rows *sql.Rows, dest ...reflect.Value) error {
+	values := make([]any, len(dest))
+	for i, d := range dest {
+		switch {
+		case hasConverter(d.Type()):
+			values[i] = new(any)
+		case utils.IsComplexType(d.Type()):
+			values[i] = new([]byte)
+		default:
+			values[i] = d.Addr().Interface()
+		}
+	}
+
+	if err := rows.Scan(values...); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for i, d := range dest {
+		if conv, ok := codec.LookupConverter(d.Type()); ok {
+			if err := convertField(d, conv, *values[i].(*any)); err != nil {
+				return err
+			}
+		} else if utils.IsComplexType(d.Type()) {
+			if err := utils.DecodeComplex(*values[i].(*[]byte), d.Addr().Interface(), nil); err != nil {
+				return errors.Wrap(err, "check if the struct matches")
+			}
+		}
+	}
+	return nil
+}
+
+// ScanKeyed 多行扫描到以 keyColumn 字段值为键的 Map 中, 不生成中间切片
+// dest 支持 *map[K]T *map[K]*T (键重复时保留最后一行) 以及 *map[K][]T *map[K][]*T (按键分组)
+func ScanKeyed(rows *Rows, dest any, keyColumn string) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("must pass a non-nil map pointer to ScanKeyed destination")
+	}
+
+	direct := value.Elem()
+	if direct.Kind() != reflect.Map {
+		return errors.New(fmt.Sprintf("expected map but got %s", direct.Kind()))
+	}
+	mapType := direct.Type()
+
+	elemType := mapType.Elem()
+	grouped := elemType.Kind() == reflect.Slice
+	if grouped {
+		elemType = elemType.Elem()
+	}
+	isPtr := elemType.Kind() == reflect.Ptr
+	base := reflectx.Deref(elemType)
+
+	fi := rows.Mapper.TypeMap(base).GetByPath(keyColumn)
+	if fi == nil {
+		return errors.New(fmt.Sprintf("key column %s not found in %s", keyColumn, base))
+	}
+	keyType := mapType.Key()
+	toKey, ok := keyConverter(fi.Field.Type, keyType)
+	if !ok {
+		return errors.New(fmt.Sprintf("key column %s type %s can not convert to %s", keyColumn, fi.Field.Type, keyType))
+	}
+
+	result := reflect.MakeMap(mapType)
+	err := ScanEach(rows, base, func(v reflect.Value) error {
+		key := toKey(reflectx.FieldByIndexes(v, fi.Index))
+
+		elem := v
+		if isPtr {
+			elem = v.Addr()
+		}
+
+		if grouped {
+			group := result.MapIndex(key)
+			if !group.IsValid() {
+				group = reflect.MakeSlice(mapType.Elem(), 0, 1)
+			}
+			elem = reflect.Append(group, elem)
+		}
+		result.SetMapIndex(key, elem)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	direct.Set(result)
+	return nil
+}
+
+// keyConverter 获取字段值转化为 Map 键的方法, 仅支持可直接赋值, 同类数值之间, 字符串之间以及数值转字符串
+// (reflect 的 int 转 string 得到的是字符而不是数字)
+func keyConverter(from, to reflect.Type) (func(reflect.Value) reflect.Value, bool) {
+	if from.AssignableTo(to) {
+		return func(v reflect.Value) reflect.Value { return v }, true
+	}
+
+	fromKind, toKind := kindClass(from.Kind()), kindClass(to.Kind())
+	switch {
+	case fromKind == "" || toKind == "":
+		return nil, false
+	case fromKind == toKind:
+		return func(v reflect.Value) reflect.Value { return v.Convert(to) }, true
+	case toKind == "string":
+		return func(v reflect.Value) reflect.Value {
+			var s string
+			switch fromKind {
+			case "int":
+				s = strconv.FormatInt(v.Int(), 10)
+			case "uint":
+				s = strconv.FormatUint(v.Uint(), 10)
+			case "float":
+				s = strconv.FormatFloat(v.Float(), 'f', -1, 64)
+			}
+			return reflect.ValueOf(s).Convert(to)
+		}, true
+	}
+	return nil, false
+}
+
+// kindClass 类型的大类 int uint float string, 其余返回空字符串
+func kindClass(k reflect.Kind) string {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	}
+	return ""
+}
+
+// ScanMap 多行扫描到 Map 切片中, 各列的值按 ColumnTypes 推断的类型解码 (int64 float64 string time.Time bool JSON), NULL 为 nil
+// hints 指定字段的解码类型, 为 nil 时全部自动推断
+func ScanMap(rows *sqlx.Rows, dest any, hints map[string]TypeHint) error {
 
 	value := reflect.ValueOf(dest)
 
@@ -221,10 +426,15 @@ func ScanMap(rows *sqlx.Rows, dest any) error {
 	direct := reflect.Indirect(value)
 	direct.SetLen(0)
 
+	columns, columnHints, err := columnHints(rows, hints)
+	if err != nil {
+		return err
+	}
+
 	for rows.Next() {
-		values := map[string]any{}
-		if err := rows.MapScan(values); err != nil {
-			return errors.WithStack(err)
+		values, err := scanTypedMap(rows, columns, columnHints)
+		if err != nil {
+			return err
 		}
 		v := reflect.ValueOf(values)
 
@@ -235,7 +445,8 @@ func ScanMap(rows *sqlx.Rows, dest any) error {
 	return errors.WithStack(rows.Err())
 }
 
-func ScanMapOnce(rows *sqlx.Rows, dest any) error {
+// ScanMapOnce 单行扫描到 Map 中, 解码方式同 ScanMap
+func ScanMapOnce(rows *sqlx.Rows, dest any, hints map[string]TypeHint) error {
 
 	value := reflect.ValueOf(dest)
 
@@ -247,11 +458,22 @@ func ScanMapOnce(rows *sqlx.Rows, dest any) error {
 		return errors.New("nil pointer passed to StructScan destination")
 	}
 	direct := reflect.Indirect(value)
-	rows.Next()
 
-	values := map[string]any{}
-	if err := rows.MapScan(values); err != nil {
-		return errors.WithStack(err)
+	columns, columnHints, err := columnHints(rows, hints)
+	if err != nil {
+		return err
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+		return errors.WithStack(sql.ErrNoRows)
+	}
+
+	values, err := scanTypedMap(rows, columns, columnHints)
+	if err != nil {
+		return err
 	}
 	v := reflect.ValueOf(values)
 	direct.Set(v)
@@ -259,6 +481,24 @@ func ScanMapOnce(rows *sqlx.Rows, dest any) error {
 	return errors.WithStack(rows.Err())
 }
 
+// skipMissing 宽松模式下报告没有对应字段的列并返回 true, 由 fieldsByTraversal 将其读取到临时变量中丢弃
+func (r *Rows) skipMissing(base reflect.Type, columns []string, fields [][]int) bool {
+	if !r.Lenient {
+		return false
+	}
+
+	if r.OnUnknown != nil {
+		var unknown []string
+		for i, traversal := range fields {
+			if len(traversal) == 0 {
+				unknown = append(unknown, columns[i])
+			}
+		}
+		r.OnUnknown(base, unknown)
+	}
+	return true
+}
+
 func missingFields(transversals [][]int) (field int, err error) {
 	for i, t := range transversals {
 		if len(t) == 0 {
@@ -268,7 +508,79 @@ func missingFields(transversals [][]int) (field int, err error) {
 	return 0, nil
 }
 
-func fieldsByTraversal(v reflect.Value, traversals [][]int, values []interface{}) error {
+// columnMeta 列对应字段的解析方式, 每次扫描前计算一次, 避免逐行反射判断
+type columnMeta struct {
+	name    string            // 字段名 (db 标签中的名称, 不含联表查询的别名)
+	options map[string]string // db 标签中的选项 (如 codec=msgpack encrypt)
+	complex bool              // 复杂数据 (未注册转换器)
+	encrypt bool              // 加密字段
+	conv    *codec.Converter  // 注册的转换器
+	ptr     []int             // 字段位于指针结构体中时 该指针字段的路径 (仅 NullPtrs)
+	group   int               // 同一指针字段下的列属于同一组, 从 1 开始
+}
+
+// raw 是否先读取原始数据, 由 parseComplexField 解析
+func (c columnMeta) raw() bool {
+	return c.complex || c.encrypt || c.conv != nil
+}
+
+// columnMetas 获取各列对应字段的解析方式
+// nullPtrs 为 true 时, 位于指针结构体字段中的列按指针字段分组, 整组为 NULL 时指针保持 nil
+func columnMetas(m *reflectx.Mapper, base reflect.Type, fields [][]int, nullPtrs bool) []columnMeta {
+	tm := m.TypeMap(base)
+	metas := make([]columnMeta, len(fields))
+	for i, traversal := range fields {
+		if len(traversal) == 0 {
+			continue
+		}
+		fi := tm.GetByTraversal(traversal)
+		if fi == nil {
+			continue
+		}
+
+		_, encrypt := fi.Options["encrypt"]
+		conv, _ := codec.LookupConverter(fi.Field.Type)
+		metas[i] = columnMeta{
+			name:    fi.Name,
+			options: fi.Options,
+			complex: conv == nil && utils.IsComplexType(fi.Field.Type),
+			encrypt: encrypt,
+			conv:    conv,
+		}
+	}
+
+	if !nullPtrs {
+		return metas
+	}
+
+	groups := make(map[string]int)
+	for i, traversal := range fields {
+		if metas[i].ptr = ptrPrefix(base, traversal); metas[i].ptr == nil {
+			continue
+		}
+		key := fmt.Sprint(metas[i].ptr)
+		if groups[key] == 0 {
+			groups[key] = len(groups) + 1
+		}
+		metas[i].group = groups[key]
+	}
+	return metas
+}
+
+// ptrPrefix 返回路径中第一个指针结构体字段的路径, 不经过指针字段时返回 nil
+func ptrPrefix(base reflect.Type, traversal []int) []int {
+	t := base
+	for depth, i := range traversal[:max(len(traversal)-1, 0)] {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Ptr {
+			return traversal[:depth+1]
+		}
+		t = field.Type
+	}
+	return nil
+}
+
+func fieldsByTraversal(v reflect.Value, traversals [][]int, values []interface{}, metas []columnMeta) error {
 	v = reflect.Indirect(v)
 	if v.Kind() != reflect.Struct {
 		return errors.New("argument not a struct")
@@ -279,14 +591,19 @@ func fieldsByTraversal(v reflect.Value, traversals [][]int, values []interface{}
 			values[i] = new(interface{})
 			continue
 		}
-		f := reflectx.FieldByIndexes(v, traversal)
 
-		if utils.IsComplexType(f.Type()) {
+		// 复杂数据, 加密字段以及注册了转换器的类型先读取原始数据, 由 parseComplexField 解析
+		// 指针结构体中的字段也先读取原始数据, 整组为 NULL 时不分配指针
+		switch {
+		case metas[i].group > 0:
+			values[i] = new(any)
+		case metas[i].encrypt || metas[i].complex:
 			values[i] = new([]byte)
-		} else {
-			values[i] = f.Addr().Interface()
+		case metas[i].conv != nil:
+			values[i] = new(any)
+		default:
+			values[i] = reflectx.FieldByIndexes(v, traversal).Addr().Interface()
 		}
-
 	}
 	return nil
 }
@@ -320,8 +637,7 @@ func isScannable(t reflect.Type) bool {
 		return true
 	}
 
-	toMap, _ := utils.StructToMap(reflect.New(t).Interface(), "db", false)
-	return len(toMap) == 0
+	return len(utils.GetStructMeta(t, "db").Fields) == 0
 }
 
 func baseType(t reflect.Type, expected reflect.Kind) (reflect.Type, error) {
@@ -370,20 +686,179 @@ func scan(r *Rows, dest ...interface{}) error {
 	return nil
 }
 
-func parseComplexField(val reflect.Value, fields [][]int, values []any) error {
+func hasConverter(typ reflect.Type) bool {
+	_, ok := codec.LookupConverter(typ)
+	return ok
+}
+
+// convertField 使用转换器将驱动读取到的值写入字段
+func convertField(f reflect.Value, conv *codec.Converter, src any) error {
+	v, err := conv.FromDB(src)
+	if err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("convert %s failed", f.Type()))
+	}
+
+	if v == nil {
+		f.Set(reflect.Zero(f.Type()))
+	} else {
+		f.Set(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+// parseComplexField 解密加密字段, 使用转换器转化字段, 并按字段 db 标签中的选项 (如 codec=msgpack) 反序列化复杂字段
+func parseComplexField(val reflect.Value, fields [][]int, values []any, metas []columnMeta) error {
+	nullGroups := nullGroups(values, metas)
+
 	for i, traversa := range fields {
 		if len(traversa) == 0 {
 			values[i] = new(interface{})
 			continue
 		}
+
+		meta := metas[i]
+		if meta.group > 0 && nullGroups[meta.group] {
+			// 整组为 NULL, 指针字段置为 nil
+			reflectx.FieldByIndexes(val, meta.ptr).SetZero()
+			continue
+		}
+		if !meta.raw() && meta.group == 0 {
+			continue
+		}
 		f := reflectx.FieldByIndexes(val, traversa)
 
-		if utils.IsComplexType(f.Type()) {
-			if err := sonic.Unmarshal(*values[i].(*[]byte), f.Addr().Interface()); err != nil {
-				return errors.Wrap(err, "check if the struct matches")
+		if meta.conv != nil && !meta.encrypt {
+			if err := convertField(f, meta.conv, *values[i].(*any)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var data []byte
+		if meta.group > 0 {
+			src := *values[i].(*any)
+			if !meta.raw() {
+				if err := assignValue(f, src); err != nil {
+					return errors.WithMessage(err, fmt.Sprintf("field %s", f.Type()))
+				}
+				continue
+			}
+			data = rawBytes(src)
+		} else {
+			data = *values[i].(*[]byte)
+		}
+
+		if meta.encrypt {
+			// NULL 保持零值
+			if data == nil {
+				continue
+			}
+
+			var err error
+			aad := codec.FieldAAD(utils.TableNameOf(val, traversa), meta.name)
+			if data, err = codec.Decrypt(data, aad); err != nil {
+				return errors.WithMessage(err, "decrypt field failed")
+			}
+			if meta.conv != nil {
+				if err = convertField(f, meta.conv, data); err != nil {
+					return err
+				}
+				continue
 			}
+			if !meta.complex {
+				if err = utils.SetFieldString(f, string(data)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if err := utils.DecodeComplex(data, f.Addr().Interface(), meta.options); err != nil {
+			return errors.Wrap(err, "check if the struct matches")
 		}
 	}
 
 	return nil
 }
+
+// nullGroups 返回整组列都为 NULL 的指针字段分组
+func nullGroups(values []any, metas []columnMeta) map[int]bool {
+	var groups map[int]bool
+	for i, meta := range metas {
+		if meta.group == 0 {
+			continue
+		}
+		if groups == nil {
+			groups = make(map[int]bool)
+		}
+		isNull := *values[i].(*any) == nil
+		if null, ok := groups[meta.group]; !ok || null {
+			groups[meta.group] = isNull
+		}
+	}
+	return groups
+}
+
+// rawBytes 驱动读取到的原始值转化为 []byte
+func rawBytes(src any) []byte {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+// assignValue 将驱动读取到的值 (int64 float64 bool []byte string time.Time nil) 写入字段
+func assignValue(f reflect.Value, src any) error {
+	if scanner, ok := f.Addr().Interface().(sql.Scanner); ok {
+		return errors.WithStack(scanner.Scan(src))
+	}
+	if src == nil {
+		f.SetZero()
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(f.Type()) {
+		f.Set(sv)
+		return nil
+	}
+
+	switch v := src.(type) {
+	case []byte:
+		if f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Uint8 {
+			f.SetBytes(append([]byte(nil), v...))
+			return nil
+		}
+		return utils.SetFieldString(f, string(v))
+	case string:
+		return utils.SetFieldString(f, v)
+	case int64, float64, bool:
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(fmt.Sprint(v))
+			return nil
+		case reflect.Bool:
+			if b, ok := v.(bool); ok {
+				f.SetBool(b)
+				return nil
+			}
+			f.SetBool(sv.Convert(reflect.TypeOf(float64(0))).Float() != 0)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if sv.Kind() != reflect.Bool {
+				f.Set(sv.Convert(f.Type()))
+				return nil
+			}
+		}
+	}
+
+	return errors.New(fmt.Sprintf("unsupported scan, storing driver.Value type %T into type %s", src, f.Type()))
+}
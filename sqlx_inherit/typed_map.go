@@ -0,0 +1,177 @@
+package sqlx_inherit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pius-x/xorm/utils"
+	"github.com/bytedance/sonic"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// TypeHint Map 查询结果中字段值的解码类型
+type TypeHint int
+
+const (
+	HintAuto   TypeHint = iota // 根据 ColumnTypes 推断
+	HintInt                    // int64
+	HintUint                   // uint64
+	HintFloat                  // float64
+	HintString                 // string
+	HintBool                   // bool
+	HintTime                   // time.Time
+	HintJSON                   // JSON 解析为 map[string]any []any 等
+	HintBytes                  // []byte
+)
+
+// columnHints 确定各列的解码类型, hints 中指定的优先, 否则根据数据库字段类型推断
+func columnHints(rows *sqlx.Rows, hints map[string]TypeHint) ([]string, []TypeHint, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	columns := make([]string, len(columnTypes))
+	columnHints := make([]TypeHint, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = ct.Name()
+		if hint, ok := hints[ct.Name()]; ok && hint != HintAuto {
+			columnHints[i] = hint
+		} else {
+			columnHints[i] = hintByDatabaseType(ct.DatabaseTypeName())
+		}
+	}
+
+	return columns, columnHints, nil
+}
+
+// hintByDatabaseType 根据数据库字段类型推断解码类型, DECIMAL 为保留精度解码为 string
+func hintByDatabaseType(typeName string) TypeHint {
+	name := strings.ToUpper(typeName)
+	switch {
+	case name == "":
+		return HintAuto
+	case strings.Contains(name, "BOOL"):
+		return HintBool
+	case strings.Contains(name, "INT") || name == "YEAR":
+		if strings.Contains(name, "UNSIGNED") {
+			return HintUint
+		}
+		return HintInt
+	case strings.Contains(name, "FLOAT") || strings.Contains(name, "DOUBLE") || name == "REAL":
+		return HintFloat
+	case strings.Contains(name, "DECIMAL") || strings.Contains(name, "NUMERIC"):
+		return HintString
+	case strings.HasPrefix(name, "JSON"):
+		return HintJSON
+	case strings.Contains(name, "DATETIME") || strings.HasPrefix(name, "TIMESTAMP") || name == "DATE":
+		return HintTime
+	case strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY") || name == "BYTEA" || name == "BIT":
+		return HintBytes
+	default:
+		return HintString
+	}
+}
+
+// scanTypedMap 扫描当前行并按解码类型转化各列的值, NULL 为 nil
+func scanTypedMap(rows *sqlx.Rows, columns []string, hints []TypeHint) (map[string]any, error) {
+	values := make([]any, len(columns))
+	for i := range values {
+		values[i] = new(any)
+	}
+	if err := rows.Scan(values...); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	m := make(map[string]any, len(columns))
+	for i, column := range columns {
+		val, err := decodeHint(*values[i].(*any), hints[i])
+		if err != nil {
+			return nil, errors.WithMessage(err, column)
+		}
+		m[column] = val
+	}
+	return m, nil
+}
+
+// decodeHint 将驱动读取到的值转化为解码类型对应的 Go 类型
+func decodeHint(src any, hint TypeHint) (any, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		if hint == HintBytes {
+			return v, nil
+		}
+		s = string(v)
+	case string:
+		s = v
+	case time.Time:
+		if hint == HintString {
+			return v.Format("2006-01-02 15:04:05.999999"), nil
+		}
+		return v, nil
+	case int64:
+		switch hint {
+		case HintBool:
+			return v != 0, nil
+		case HintFloat:
+			return float64(v), nil
+		case HintUint:
+			return uint64(v), nil
+		case HintString:
+			return strconv.FormatInt(v, 10), nil
+		}
+		return v, nil
+	case float64:
+		switch hint {
+		case HintInt:
+			return int64(v), nil
+		case HintString:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+		return v, nil
+	default:
+		if hint == HintString {
+			return fmt.Sprint(v), nil
+		}
+		return v, nil
+	}
+
+	switch hint {
+	case HintInt:
+		i, err := strconv.ParseInt(s, 10, 64)
+		return i, errors.WithStack(err)
+	case HintUint:
+		u, err := strconv.ParseUint(s, 10, 64)
+		return u, errors.WithStack(err)
+	case HintFloat:
+		f, err := strconv.ParseFloat(s, 64)
+		return f, errors.WithStack(err)
+	case HintBool:
+		b, err := strconv.ParseBool(s)
+		return b, errors.WithStack(err)
+	case HintTime:
+		// MySQL 零值日期
+		if strings.HasPrefix(s, "0000-00-00") {
+			return time.Time{}, nil
+		}
+		return utils.ParseTime(s)
+	case HintJSON:
+		var v any
+		if err := sonic.UnmarshalString(s, &v); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return v, nil
+	case HintBytes:
+		return []byte(s), nil
+	default:
+		return s, nil
+	}
+}
@@ -0,0 +1,101 @@
+package xorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// stubDB 测试用的数据库驱动, 按语句返回预设的结果并记录执行过的语句
+type stubDB struct {
+	mu      sync.Mutex
+	queries []string
+
+	query func(query string, args []driver.Value) ([]string, [][]driver.Value, error)
+	exec  func(query string, args []driver.Value) error
+}
+
+func stubCli(db *stubDB) *Cli {
+	return &Cli{DB: sqlx.NewDb(sql.OpenDB(db), "mysql")}
+}
+
+func (db *stubDB) record(query string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.queries = append(db.queries, query)
+}
+
+func (db *stubDB) Queries() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]string(nil), db.queries...)
+}
+
+func (db *stubDB) Connect(context.Context) (driver.Conn, error) { return stubConn{db}, nil }
+func (db *stubDB) Driver() driver.Driver                        { return stubDriver{db} }
+
+type stubDriver struct{ db *stubDB }
+
+func (d stubDriver) Open(string) (driver.Conn, error) { return stubConn(d), nil }
+
+type stubConn struct{ db *stubDB }
+
+func (c stubConn) Prepare(query string) (driver.Stmt, error) { return stubStmt{c.db, query}, nil }
+func (c stubConn) Close() error                              { return nil }
+func (c stubConn) Begin() (driver.Tx, error)                 { return stubTx{}, nil }
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubStmt struct {
+	db    *stubDB
+	query string
+}
+
+func (s stubStmt) Close() error  { return nil }
+func (s stubStmt) NumInput() int { return -1 }
+
+func (s stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.record(s.query)
+	if s.db.exec != nil {
+		if err := s.db.exec(s.query, args); err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.record(s.query)
+	if s.db.query == nil {
+		return &stubRows{}, nil
+	}
+	columns, rows, err := s.db.query(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &stubRows{columns: columns, rows: rows}, nil
+}
+
+type stubRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string { return r.columns }
+func (r *stubRows) Close() error      { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
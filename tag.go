@@ -0,0 +1,67 @@
+package xorm
+
+import (
+	"reflect"
+	"strings"
+)
+
+// XormTag 模型扩展标签, 多个选项以空格分隔
+// 如: `xorm:"pk autoincr"` `xorm:"created"` `xorm:"hasmany:player_id"`
+const XormTag = "xorm"
+
+// 默认主键字段
+const defaultPk = "id"
+
+// parseXormTag 解析 xorm 标签, 选项名 -> 选项值 (无值的选项为空字符串)
+func parseXormTag(tag string) map[string]string {
+	opts := make(map[string]string)
+	for _, opt := range strings.Fields(tag) {
+		key, val, _ := strings.Cut(opt, ":")
+		opts[strings.ToLower(key)] = val
+	}
+	return opts
+}
+
+// columnName 获取字段的 db 标签中的字段名
+func columnName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get(Tag), ",")
+	return name
+}
+
+// pkColumn 获取结构体的主键字段名 (xorm:"pk"), 未声明时默认为 id
+func pkColumn(typ reflect.Type) string {
+	if col := findColumnByOption(typ, "pk"); col != "" {
+		return col
+	}
+	return defaultPk
+}
+
+// findColumnByOption 查找带有指定 xorm 选项的字段名 (包含匿名嵌入结构体)
+func findColumnByOption(typ reflect.Type, option string) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if col := findColumnByOption(field.Type, option); col != "" {
+				return col
+			}
+		}
+
+		if _, ok := parseXormTag(field.Tag.Get(XormTag))[option]; ok {
+			if col := columnName(field); col != "" {
+				return col
+			}
+		}
+	}
+	return ""
+}
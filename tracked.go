@@ -0,0 +1,123 @@
+package xorm
+
+import (
+	"bytes"
+	dbSql "database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/Pius-x/xorm/codec"
+	"github.com/Pius-x/xorm/utils"
+	"github.com/pkg/errors"
+)
+
+// Tracked 嵌入到模型结构体中开启脏字段追踪, 通过 Search 查询到的记录会保存字段原始值的快照
+// 修改后调用 Save 只更新发生变化的字段 如:
+//
+//	type Player struct {
+//		xorm.Tracked
+//		ID   int64  `db:"id" xorm:"pk"`
+//		Gold int64  `db:"gold"`
+//	}
+type Tracked struct {
+	snapshot map[string]any
+}
+
+type tracker interface {
+	trackedSnapshot() *map[string]any
+}
+
+var trackerType = reflect.TypeOf((*tracker)(nil)).Elem()
+
+func (t *Tracked) trackedSnapshot() *map[string]any {
+	return &t.snapshot
+}
+
+// Save 保存追踪的记录, 只更新与快照相比发生变化的字段, 没有变化时不执行语句 (返回的 Result 为 nil)
+// 记录没有快照时 (如不是通过 Search 查询到的) 更新除主键外的所有字段
+// record 嵌入了 Tracked 的模型结构体指针, 以主键 (xorm:"pk", 默认为 id) 作为更新条件
+func (cli *Cli) Save(record SqlxTabler) (dbSql.Result, error) {
+	t, ok := record.(tracker)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("%T expect pointer to struct embedding xorm.Tracked", record))
+	}
+
+	current, err := utils.StructToMap(record, Tag, true)
+	if err != nil {
+		return nil, errors.WithMessage(err, "转化成Map出错")
+	}
+
+	pk := pkColumn(reflect.TypeOf(record))
+	if _, ok = current[pk]; !ok {
+		return nil, errors.New(fmt.Sprintf("primary key %s not found in %T", pk, record))
+	}
+
+	snapshot := *t.trackedSnapshot()
+	updateMap := current
+	if snapshot != nil {
+		if !reflect.DeepEqual(snapshot[pk], current[pk]) {
+			return nil, errors.New("primary key changed since loaded")
+		}
+
+		updateMap = map[string]any{pk: current[pk]}
+		for tag, val := range current {
+			if old, ok := snapshot[tag]; !ok || fieldChanged(old, val, codec.FieldAAD(record.TableName(), tag)) {
+				updateMap[tag] = val
+			}
+		}
+		if len(updateMap) == 1 {
+			return nil, nil
+		}
+	}
+
+	order := utils.GetStructMeta(reflect.TypeOf(record), Tag).WriteColumns
+	query, args, err := cli.buildUpdateQuery(record.TableName(), updateMap, order, []string{pk})
+	if err != nil {
+		return nil, errors.WithMessage(err, "构建更新语句出错")
+	}
+
+	result, err := cli.Exec(query, args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "save 语句执行出错")
+	}
+
+	*t.trackedSnapshot() = current
+	return result, nil
+}
+
+// takeSnapshots 为查询结果中嵌入了 Tracked 的记录保存快照
+func (cli *Cli) takeSnapshots(dest any) error {
+	structs, typ := collectStructs(dest)
+	if len(structs) == 0 || !reflect.PtrTo(typ).Implements(trackerType) {
+		return nil
+	}
+
+	for _, v := range structs {
+		if !v.CanAddr() {
+			continue
+		}
+
+		snapshot, err := utils.StructToMap(v.Addr().Interface(), Tag, true)
+		if err != nil {
+			return errors.WithMessage(err, "保存快照出错")
+		}
+		*v.Addr().Interface().(tracker).trackedSnapshot() = snapshot
+	}
+
+	return nil
+}
+
+// fieldChanged 比较快照与当前值是否不同, 加密字段每次加密的密文不同, 解密后比较明文
+// aad 加密字段的附加认证数据
+func fieldChanged(old, cur any, aad []byte) bool {
+	oldData, ok1 := old.([]byte)
+	curData, ok2 := cur.([]byte)
+	if ok1 && ok2 && codec.IsEncrypted(oldData) && codec.IsEncrypted(curData) {
+		oldPlain, err1 := codec.Decrypt(oldData, aad)
+		curPlain, err2 := codec.Decrypt(curData, aad)
+		if err1 == nil && err2 == nil {
+			return !bytes.Equal(oldPlain, curPlain)
+		}
+	}
+	return !reflect.DeepEqual(old, cur)
+}
@@ -0,0 +1,374 @@
+package xorm
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Pius-x/xorm/sqlx_inherit"
+	"github.com/Pius-x/xorm/utils"
+	"github.com/bytedance/sonic"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/pkg/errors"
+)
+
+// NDJSON 解码时保留数字精度 (如 bigint)
+var ndjsonCodec = sonic.Config{UseNumber: true}.Froze()
+
+// CSV 中的 NULL, 与 LOAD DATA 默认的 NULL 一致, 用于区分 NULL 与空字符串
+const csvNull = `\N`
+
+// Format 导入导出的数据格式
+type Format int
+
+const (
+	FormatCSV    Format = iota // 第一行为字段名, NULL 写为 \N (因此字符串 \N 本身会被读取为 NULL)
+	FormatNDJSON               // 每行一个 JSON 对象, 键为字段名
+)
+
+// ImportMode 导入时的写入方式
+type ImportMode int
+
+const (
+	ImportInsert  ImportMode = iota // INSERT
+	ImportUpsert                    // INSERT ... ON DUPLICATE KEY UPDATE
+	ImportIgnore                    // INSERT IGNORE
+	ImportReplace                   // REPLACE
+)
+
+// 导入时每批写入的记录数
+const importChunkSize = 500
+
+// LineError 导入时某一行的错误
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ImportReport 导入结果
+type ImportReport struct {
+	Lines    int         // 读取的数据行数 (不含 CSV 表头)
+	Imported int         // 成功写入的记录数
+	Errors   []LineError // 解析失败被跳过的行
+}
+
+// Export 按模型结构体流式导出表数据
+// w 输出
+// model 模型结构体指针 如: &User{}
+// format FormatCSV 或 FormatNDJSON
+// where 条件语句 如: "WHERE id > ?" 参数放在args中
+func (cli *Cli) Export(w io.Writer, model SqlxTabler, format Format, where string, args ...any) error {
+	tb, tags, err := cli.toTbAndTags(model)
+	if err != nil {
+		return errors.WithMessage(err, "获取结构体表名和Tags出错")
+	}
+
+	where, args, err = sqlx.In(where, args...)
+	if err != nil {
+		return errors.Wrap(err, "参数解析失败")
+	}
+
+	query, err := cli.buildSearchQuery(tb, tags, where)
+	if err != nil {
+		return errors.WithMessage(err, "构建查询语句出错")
+	}
+
+	r, err := cli.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	rows := cli.newRows(r)
+	defer rows.Close()
+
+	var write func(v reflect.Value) error
+	var flush func() error
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err = cw.Write(tags); err != nil {
+			return errors.WithStack(err)
+		}
+		record := make([]string, len(tags))
+		write = func(v reflect.Value) error {
+			smap := make(map[string]any, len(tags))
+			if err := utils.ReflectToMap(smap, v, Tag, false); err != nil {
+				return err
+			}
+			for i, tag := range tags {
+				// 复杂字段不论使用何种编解码器存储, 导出时均为 JSON
+				if val := smap[tag]; val != nil && utils.IsComplexType(reflect.TypeOf(val)) {
+					data, err := sonic.MarshalString(val)
+					if err != nil {
+						return errors.WithStack(err)
+					}
+					smap[tag] = data
+				}
+				s, null, err := utils.FieldString(smap[tag])
+				if err != nil {
+					return err
+				}
+				if null {
+					s = csvNull
+				}
+				record[i] = s
+			}
+			return errors.WithStack(cw.Write(record))
+		}
+		flush = func() error {
+			cw.Flush()
+			return errors.WithStack(cw.Error())
+		}
+	case FormatNDJSON:
+		bw := bufio.NewWriter(w)
+		write = func(v reflect.Value) error {
+			smap := make(map[string]any, len(tags))
+			if err := utils.ReflectToMap(smap, v, Tag, false); err != nil {
+				return err
+			}
+			for tag, val := range smap {
+				// sql.NullString 等类型输出其数据库值, 自行实现 JSON 序列化的 (如 xorm.Null) 除外
+				if _, ok := val.(json.Marshaler); ok {
+					continue
+				}
+				if valuer, ok := val.(driver.Valuer); ok {
+					if smap[tag], err = valuer.Value(); err != nil {
+						return errors.WithStack(err)
+					}
+				}
+				if b, ok := smap[tag].([]byte); ok {
+					smap[tag] = string(b)
+				}
+			}
+			line, err := sonic.Marshal(smap)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			_, _ = bw.Write(line)
+			return errors.WithStack(bw.WriteByte('\n'))
+		}
+		flush = func() error {
+			return errors.WithStack(bw.Flush())
+		}
+	default:
+		return errors.New(fmt.Sprintf("unsupported format %d", format))
+	}
+
+	base := reflect.Indirect(reflect.ValueOf(model)).Type()
+	if err = sqlx_inherit.ScanEach(rows, base, write); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("导出出错, sql:%s", query))
+	}
+
+	return flush()
+}
+
+// Import 按模型结构体导入数据, 分批写入, 解析失败的行会被跳过并记录在 ImportReport.Errors 中
+// 复杂字段在输入中为 JSON, 写入时按字段的编解码器重新序列化
+// CSV 表头中有结构体不存在的字段时不导入任何数据; NDJSON 中含有不存在字段的行被跳过
+// 写入出错时之前的批次已经写入 (数量见 ImportReport.Imported), 错误信息中带有出错批次的行号范围
+// r 输入
+// model 模型结构体指针 如: &User{}
+// format FormatCSV 或 FormatNDJSON
+// mode 写入方式 ImportInsert ImportUpsert ImportIgnore ImportReplace
+func (cli *Cli) Import(r io.Reader, model SqlxTabler, format Format, mode ImportMode) (ImportReport, error) {
+	var report ImportReport
+
+	base := reflect.Indirect(reflect.ValueOf(model)).Type()
+	if base.Kind() != reflect.Struct {
+		return report, errors.New("expect struct model")
+	}
+	typeMap := cli.Mapper.TypeMap(base)
+
+	chunk := make([]SqlxTabler, 0, importChunkSize)
+	var firstLine, lastLine int // 当前批次的行号范围
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if err := cli.importChunk(chunk, mode); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("写入第 %d-%d 行出错", firstLine, lastLine))
+		}
+		report.Imported += len(chunk)
+		chunk = chunk[:0]
+		return nil
+	}
+
+	// 解析一行数据 columns 与 values 一一对应
+	addRow := func(line int, columns []string, values []any) error {
+		report.Lines++
+
+		v := reflect.New(base)
+		for i, col := range columns {
+			fi := typeMap.GetByPath(col)
+			if fi == nil {
+				report.Errors = append(report.Errors, LineError{Line: line, Err: errors.New(fmt.Sprintf("column %s not found in %s", col, base))})
+				return nil
+			}
+			if err := setImportValue(reflectx.FieldByIndexes(v.Elem(), fi.Index), values[i]); err != nil {
+				report.Errors = append(report.Errors, LineError{Line: line, Err: errors.WithMessage(err, col)})
+				return nil
+			}
+		}
+
+		if len(chunk) == 0 {
+			firstLine = line
+		}
+		lastLine = line
+		chunk = append(chunk, v.Interface().(SqlxTabler))
+		if len(chunk) >= importChunkSize {
+			return flush()
+		}
+		return nil
+	}
+
+	var err error
+	switch format {
+	case FormatCSV:
+		err = cli.importCSV(r, typeMap, addRow, &report)
+	case FormatNDJSON:
+		err = cli.importNDJSON(r, addRow, &report)
+	default:
+		return report, errors.New(fmt.Sprintf("unsupported format %d", format))
+	}
+	if err != nil {
+		return report, err
+	}
+
+	return report, flush()
+}
+
+func (cli *Cli) importCSV(r io.Reader, typeMap *reflectx.StructMap, addRow func(int, []string, []any) error, report *ImportReport) error {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return errors.Wrap(err, "读取 CSV 表头出错")
+	}
+	columns := append([]string(nil), header...)
+
+	// 表头中的字段均需存在, 避免每一行都出错
+	for _, col := range columns {
+		if typeMap.GetByPath(col) == nil {
+			return errors.New(fmt.Sprintf("CSV header: column %s not found", col))
+		}
+	}
+
+	values := make([]any, len(columns))
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		// 字段数不一致, 引号不匹配等格式错误跳过该行
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.Lines++
+			report.Errors = append(report.Errors, LineError{Line: parseErr.StartLine, Err: errors.WithStack(parseErr.Err)})
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "读取 CSV 出错")
+		}
+
+		line, _ := cr.FieldPos(0)
+		for i := range values {
+			if record[i] == csvNull {
+				values[i] = nil
+			} else {
+				values[i] = record[i]
+			}
+		}
+		if err = addRow(line, columns, values); err != nil {
+			return err
+		}
+	}
+}
+
+func (cli *Cli) importNDJSON(r io.Reader, addRow func(int, []string, []any) error, report *ImportReport) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var row map[string]any
+		if err := ndjsonCodec.UnmarshalFromString(text, &row); err != nil {
+			report.Lines++
+			report.Errors = append(report.Errors, LineError{Line: line, Err: errors.WithStack(err)})
+			continue
+		}
+
+		columns := utils.MapKeys(row, true)
+		values := make([]any, len(columns))
+		for i, col := range columns {
+			values[i] = row[col]
+		}
+		if err := addRow(line, columns, values); err != nil {
+			return err
+		}
+	}
+
+	return errors.WithStack(scanner.Err())
+}
+
+// importChunk 按导入方式写入一批记录
+func (cli *Cli) importChunk(records []SqlxTabler, mode ImportMode) error {
+	var err error
+	switch mode {
+	case ImportInsert:
+		_, err = cli.insert(records)
+	case ImportUpsert:
+		_, err = cli.upsert(records, nil)
+	case ImportIgnore:
+		_, err = cli.insertWith(insertIgnoreVerb, records)
+	case ImportReplace:
+		_, err = cli.insertWith(replaceVerb, records)
+	default:
+		return errors.New(fmt.Sprintf("unsupported import mode %d", mode))
+	}
+	return err
+}
+
+// setImportValue 将导入的值写入结构体字段
+// CSV 中的值均为字符串; NDJSON 中的值为 string json.Number bool nil 或嵌套的 map slice
+func setImportValue(f reflect.Value, value any) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		data, err := sonic.MarshalString(v)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		s = data
+	}
+
+	return utils.SetFieldString(f, s)
+}
@@ -0,0 +1,113 @@
+package xorm
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type transferUser struct {
+	ID   int64          `db:"id"`
+	Name sql.NullString `db:"name"`
+	Nick Null[string]   `db:"nick"`
+	Tags []string       `db:"tags"`
+}
+
+func (transferUser) TableName() string { return "user" }
+
+// 导出时区分 NULL 与空字符串, 导入后保持不变
+func TestCSVNullRoundTrip(t *testing.T) {
+	columns := []string{"id", "name", "nick", "tags"}
+	rows := [][]driver.Value{
+		{int64(1), "", "", []byte(`["a"]`)},
+		{int64(2), nil, nil, []byte(`null`)},
+		{int64(3), `a,"b"`, "c", []byte(`[]`)},
+	}
+	db := &stubDB{query: func(string, []driver.Value) ([]string, [][]driver.Value, error) {
+		return columns, rows, nil
+	}}
+
+	var buf bytes.Buffer
+	if err := stubCli(db).Export(&buf, &transferUser{}, FormatCSV, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,name,nick,tags\n" +
+		"1,,,\"[\"\"a\"\"]\"\n" +
+		"2,\\N,\\N,null\n" +
+		"3,\"a,\"\"b\"\"\",c,[]\n"
+	if buf.String() != want {
+		t.Fatalf("csv = %q, want %q", buf.String(), want)
+	}
+
+	var args [][]driver.Value
+	db = &stubDB{exec: func(_ string, values []driver.Value) error {
+		for i := 0; i < len(values); i += len(columns) {
+			args = append(args, values[i:i+len(columns)])
+		}
+		return nil
+	}}
+	report, err := stubCli(db).Import(strings.NewReader(buf.String()), &transferUser{}, FormatCSV, ImportInsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Lines != 3 || report.Imported != 3 || len(report.Errors) != 0 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	for i, row := range rows {
+		// 复杂字段以 JSON 字符串写入
+		wantRow := append([]driver.Value{}, row...)
+		wantRow[3] = string(wantRow[3].([]byte))
+		if !reflect.DeepEqual(args[i], wantRow) {
+			t.Errorf("row %d = %#v, want %#v", i, args[i], wantRow)
+		}
+	}
+}
+
+func TestImportUnknownColumns(t *testing.T) {
+	db := &stubDB{}
+	_, err := stubCli(db).Import(strings.NewReader("id,name,age\n1,a,18\n"), &transferUser{}, FormatCSV, ImportInsert)
+	if err == nil || !strings.Contains(err.Error(), "column age not found") {
+		t.Fatalf("err = %v", err)
+	}
+	if queries := db.Queries(); len(queries) != 0 {
+		t.Errorf("queries = %q, want none", queries)
+	}
+
+	ndjson := `{"id":1,"name":"a"}` + "\n" + `{"id":2,"age":18}` + "\n" + `{"id":3}` + "\n"
+	report, err := stubCli(db).Import(strings.NewReader(ndjson), &transferUser{}, FormatNDJSON, ImportInsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Lines != 3 || report.Imported != 2 || len(report.Errors) != 1 || report.Errors[0].Line != 2 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestImportChunkErrorLines(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("id,name\n")
+	for i := 0; i < importChunkSize+10; i++ {
+		input.WriteString("1,a\n")
+	}
+
+	calls := 0
+	db := &stubDB{exec: func(string, []driver.Value) error {
+		if calls++; calls == 2 {
+			return errors.New("duplicate entry")
+		}
+		return nil
+	}}
+	report, err := stubCli(db).Import(strings.NewReader(input.String()), &transferUser{}, FormatCSV, ImportInsert)
+	if err == nil || !strings.Contains(err.Error(), "写入第 502-511 行出错") || !strings.Contains(err.Error(), "duplicate entry") {
+		t.Fatalf("err = %v", err)
+	}
+	if report.Imported != importChunkSize {
+		t.Errorf("imported = %d, want %d", report.Imported, importChunkSize)
+	}
+}
@@ -0,0 +1,55 @@
+package xorm
+
+// UpsertOption Upsert 选项
+type UpsertOption func(*upsertOptions)
+
+type upsertOptions struct {
+	updateCols  []string
+	excludeCols []string
+	exprs       []upsertExpr
+	rowAlias    string
+}
+
+type upsertExpr struct {
+	col  string
+	expr string
+}
+
+// UpdateCols 冲突时只更新指定的字段 (默认更新所有字段)
+func UpdateCols(cols ...string) UpsertOption {
+	return func(o *upsertOptions) {
+		o.updateCols = append(o.updateCols, cols...)
+	}
+}
+
+// ExcludeCols 冲突时不更新指定的字段, 只在插入时写入 如: created_at
+func ExcludeCols(cols ...string) UpsertOption {
+	return func(o *upsertOptions) {
+		o.excludeCols = append(o.excludeCols, cols...)
+	}
+}
+
+// UpdateExpr 冲突时使用表达式更新字段, 表达式原样拼接 (不支持参数)
+// 如: xorm.UpdateExpr("count", "`count` + VALUES(`count`)"), 使用 RowAlias 时为 "`count` + `new`.`count`"
+func UpdateExpr(col string, expr string) UpsertOption {
+	return func(o *upsertOptions) {
+		o.exprs = append(o.exprs, upsertExpr{col: col, expr: expr})
+	}
+}
+
+// UpdateIncr 冲突时累加字段 `col` = `col` + 新值, 自动适配 RowAlias
+func UpdateIncr(cols ...string) UpsertOption {
+	return func(o *upsertOptions) {
+		for _, col := range cols {
+			o.exprs = append(o.exprs, upsertExpr{col: col})
+		}
+	}
+}
+
+// RowAlias 使用 MySQL 8.0.20+ 的 INSERT ... AS alias ON DUPLICATE KEY UPDATE col = alias.col 语法
+// 代替已废弃的 VALUES(col)
+func RowAlias(alias string) UpsertOption {
+	return func(o *upsertOptions) {
+		o.rowAlias = alias
+	}
+}
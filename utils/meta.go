@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"reflect"
+	"sync"
+)
+
+// FieldMeta 结构体中带标签字段的元数据
+type FieldMeta struct {
+	Name    string            // 标签中的字段名
+	Index   []int             // 字段索引, 匿名嵌入结构体中的字段为多级索引
+	Type    reflect.Type      // 字段类型
+	Options map[string]string // 标签中的选项 如: codec=msgpack encrypt
+	Complex bool              // 是否为复杂数据结构
+	Encrypt bool              // 是否加密
+	Blind   string            // 盲索引字段名
+
+	Column      int // 在 WriteColumns 中的位置
+	BlindColumn int // 盲索引字段在 WriteColumns 中的位置
+}
+
+// StructMeta 结构体的元数据, 按类型缓存, 只读
+type StructMeta struct {
+	Fields       []FieldMeta          // 字段, 按声明顺序
+	Columns      []string             // 字段名 (去重), 按声明顺序
+	WriteColumns []string             // 写入时的字段名, 加密字段的盲索引字段紧跟在其后
+	Blinds       map[string]string    // 盲索引字段 => 加密字段
+	Encrypted    map[string]FieldMeta // 加密字段名 => 字段
+}
+
+type metaKey struct {
+	typ reflect.Type
+	tag string
+}
+
+var metaCache sync.Map // metaKey => *StructMeta
+
+// GetStructMeta 获取结构体类型的元数据, 并发安全, 同一类型只解析一次
+func GetStructMeta(typ reflect.Type, tag string) *StructMeta {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	key := metaKey{typ: typ, tag: tag}
+	if meta, ok := metaCache.Load(key); ok {
+		return meta.(*StructMeta)
+	}
+
+	meta := &StructMeta{Blinds: make(map[string]string), Encrypted: make(map[string]FieldMeta)}
+	if typ.Kind() == reflect.Struct {
+		meta.Fields = collectFields(nil, typ, tag, nil)
+	}
+
+	// 同名字段 (如嵌入结构体中被覆盖的字段) 只写入一次, 位置取第一次出现的位置
+	positions := make(map[string]int, len(meta.Fields))
+	addWrite := func(name string) int {
+		if pos, ok := positions[name]; ok {
+			return pos
+		}
+		positions[name] = len(meta.WriteColumns)
+		meta.WriteColumns = append(meta.WriteColumns, name)
+		return positions[name]
+	}
+	for i := range meta.Fields {
+		f := &meta.Fields[i]
+		if !InSlice(f.Name, meta.Columns) {
+			meta.Columns = append(meta.Columns, f.Name)
+		}
+		f.Column = addWrite(f.Name)
+		if f.Blind != "" {
+			meta.Blinds[f.Blind] = f.Name
+			f.BlindColumn = addWrite(f.Blind)
+		}
+		if f.Encrypt {
+			meta.Encrypted[f.Name] = *f
+		}
+	}
+
+	actual, _ := metaCache.LoadOrStore(key, meta)
+	return actual.(*StructMeta)
+}
+
+// collectFields 按声明顺序收集带标签的字段, 匿名嵌入结构体的字段在其位置展开
+func collectFields(fields []FieldMeta, typ reflect.Type, tag string, index []int) []FieldMeta {
+	for i := 0; i < typ.NumField(); i++ {
+		typField := typ.Field(i)
+		if !typField.IsExported() {
+			continue
+		}
+
+		fieldIndex := append(append(make([]int, 0, len(index)+1), index...), i)
+		if typField.Anonymous && typField.Type.Kind() == reflect.Struct {
+			fields = collectFields(fields, typField.Type, tag, fieldIndex)
+		}
+
+		name, options := ParseTag(typField.Tag.Get(tag))
+		if name == "" {
+			continue
+		}
+
+		_, encrypt := options["encrypt"]
+		fields = append(fields, FieldMeta{
+			Name:    name,
+			Index:   fieldIndex,
+			Type:    typField.Type,
+			Options: options,
+			Complex: IsComplexType(typField.Type),
+			Encrypt: encrypt,
+			Blind:   options["blind"],
+		})
+	}
+	return fields
+}
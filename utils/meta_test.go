@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+type BenchEmbed struct {
+	CreatedAt int64 `db:"created_at"`
+}
+
+type benchRecord struct {
+	BenchEmbed
+	ID    int64          `db:"id"`
+	Name  string         `db:"name"`
+	Level int32          `db:"level"`
+	Attrs map[string]int `db:"attrs"`
+}
+
+func TestAppendStructValuesMatchesStructToMap(t *testing.T) {
+	record := &benchRecord{BenchEmbed{1}, 2, "a", 3, map[string]int{"hp": 1}}
+
+	smap, err := StructToMap(record, "db", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := AppendStructValues(nil, reflect.ValueOf(record), "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := GetStructMeta(reflect.TypeOf(record), "db").WriteColumns
+	if want := []string{"created_at", "id", "name", "level", "attrs"}; !reflect.DeepEqual(columns, want) {
+		t.Fatalf("columns = %v, want %v", columns, want)
+	}
+	for i, col := range columns {
+		if !reflect.DeepEqual(values[i], smap[col]) {
+			t.Errorf("%s = %v, want %v", col, values[i], smap[col])
+		}
+	}
+}
+
+func BenchmarkGetStructMeta(b *testing.B) {
+	typ := reflect.TypeOf(benchRecord{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetStructMeta(typ, "db")
+	}
+}
+
+// 10k 条记录逐行转化为 Map
+func BenchmarkReflectToMap10k(b *testing.B) {
+	records := benchRecords(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, record := range records {
+			if _, err := StructToMap(record, "db", true); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// 10k 条记录按字段顺序追加到同一个参数切片中
+func BenchmarkAppendStructValues10k(b *testing.B) {
+	records := benchRecords(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		values := make([]any, 0, 5*len(records))
+		for _, record := range records {
+			var err error
+			if values, err = AppendStructValues(values, reflect.ValueOf(record), "db"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func benchRecords(n int) []*benchRecord {
+	records := make([]*benchRecord, n)
+	for i := range records {
+		records[i] = &benchRecord{BenchEmbed{1}, int64(i), "player", 10, map[string]int{"hp": 1}}
+	}
+	return records
+}
@@ -1,10 +1,16 @@
 package utils
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Pius-x/xorm/codec"
 	"github.com/bytedance/sonic"
 	"github.com/pkg/errors"
 	"golang.org/x/exp/constraints"
@@ -12,6 +18,15 @@ import (
 
 var ComplexType = []reflect.Kind{reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr}
 
+// TimeLayouts 字符串转化为时间时支持的格式
+var TimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999", "2006-01-02"}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
 // Concat 字符串拼接
 func Concat(strArr ...string) string {
 
@@ -52,8 +67,11 @@ func InSlice[T constraints.Ordered | reflect.Kind](needle T, haystack []T) bool
 func StructToMap(structInfo any, tag string, stringify bool) (map[string]any, error) {
 
 	val := reflect.Indirect(reflect.ValueOf(structInfo))
+	if val.Kind() != reflect.Struct {
+		return nil, errors.New("expect struct")
+	}
 
-	smap := make(map[string]any)
+	smap := make(map[string]any, len(GetStructMeta(val.Type(), tag).WriteColumns))
 	if err := ReflectToMap(smap, val, tag, stringify); err != nil {
 		return nil, err
 	}
@@ -68,37 +86,252 @@ func ReflectToMap(smap map[string]any, val reflect.Value, tag string, stringify
 		return errors.New("expect struct")
 
 	}
-	for i := 0; i < typ.NumField(); i++ {
-		typField := typ.Field(i)
-		if !typField.IsExported() {
+
+	meta := GetStructMeta(typ, tag)
+	var table string
+	if len(meta.Encrypted) > 0 {
+		table = TableNameOf(val, nil)
+	}
+	for i := range meta.Fields {
+		field := &meta.Fields[i]
+
+		if !stringify {
+			smap[field.Name] = val.FieldByIndex(field.Index).Interface()
 			continue
 		}
 
-		if typField.Anonymous && typField.Type.Kind() == reflect.Struct {
-			if err := ReflectToMap(smap, val.Field(i), tag, stringify); err != nil {
-				return err
-			}
+		value, blind, err := writeValue(field, val, table)
+		if err != nil {
+			return errors.WithMessage(err, field.Name)
 		}
+		smap[field.Name] = value
+		if field.Encrypt && field.Blind != "" {
+			smap[field.Blind] = blind
+		}
+	}
 
-		tagName := typ.Field(i).Tag.Get(tag)
-		if tagName == "" {
-			continue
+	return nil
+}
+
+// AppendStructValues 将结构体写入数据库的值按 GetStructMeta 的 WriteColumns 顺序追加到 dst 中
+// 与 StructToMap(stringify) 的值相同, 批量写入时不需要为每行创建 Map
+func AppendStructValues(dst []any, val reflect.Value, tag string) ([]any, error) {
+	val = reflect.Indirect(val)
+	if val.Kind() != reflect.Struct {
+		return nil, errors.New("expect struct")
+	}
+
+	meta := GetStructMeta(val.Type(), tag)
+	var table string
+	if len(meta.Encrypted) > 0 {
+		table = TableNameOf(val, nil)
+	}
+
+	start := len(dst)
+	dst = append(dst, make([]any, len(meta.WriteColumns))...)
+	row := dst[start:]
+	for i := range meta.Fields {
+		field := &meta.Fields[i]
+
+		value, blind, err := writeValue(field, val, table)
+		if err != nil {
+			return nil, errors.WithMessage(err, field.Name)
+		}
+		row[field.Column] = value
+		if field.Encrypt && field.Blind != "" {
+			row[field.BlindColumn] = blind
 		}
+	}
 
-		if stringify && IsComplexType(typField.Type) {
-			marshal, err := sonic.MarshalString(val.Field(i).Interface())
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			smap[tagName] = marshal
-		} else {
-			smap[tagName] = val.Field(i).Interface()
+	return dst, nil
+}
+
+// writeValue 字段写入数据库的值: 注册了转换器的类型使用转换后的值, 加密字段返回密文及盲索引, 复杂字段按编解码器序列化
+func writeValue(field *FieldMeta, val reflect.Value, table string) (any, any, error) {
+	fieldVal := val.FieldByIndex(field.Index).Interface()
+
+	conv, hasConv := codec.LookupConverter(field.Type)
+	if hasConv {
+		var err error
+		if fieldVal, err = conv.ToDB(fieldVal); err != nil {
+			return nil, nil, err
 		}
 	}
 
+	switch {
+	case field.Encrypt:
+		return encryptValue(table, field.Name, fieldVal, field.Options)
+	case !hasConv && field.Complex:
+		marshal, err := EncodeComplex(fieldVal, field.Options)
+		return marshal, nil, err
+	default:
+		return fieldVal, nil, nil
+	}
+}
+
+// ParseTag 解析标签, 返回字段名及逗号后的选项 如: "attrs,codec=msgpack" 返回 attrs 与 {codec: msgpack}
+func ParseTag(tagValue string) (string, map[string]string) {
+	name, opts, found := strings.Cut(tagValue, ",")
+	if !found {
+		return name, nil
+	}
+
+	options := make(map[string]string)
+	for _, opt := range strings.Split(opts, ",") {
+		k, v, _ := strings.Cut(opt, "=")
+		options[k] = v
+	}
+	return name, options
+}
+
+// EncodeComplex 按字段选项中的编解码器序列化复杂字段, 文本编码返回字符串, 二进制编码或压缩后返回 []byte
+func EncodeComplex(v any, options map[string]string) (any, error) {
+	c, err := codec.Get(options["codec"])
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := options["compress"]; name != "" {
+		return codec.Compress(name, data)
+	}
+
+	if codec.IsBinary(c) {
+		return data, nil
+	}
+	return string(data), nil
+}
+
+// DecodeComplex 按字段选项中的编解码器反序列化复杂字段, 压缩过的数据先解压
+func DecodeComplex(data []byte, v any, options map[string]string) error {
+	c, err := codec.Get(options["codec"])
+	if err != nil {
+		return err
+	}
+
+	if data, err = codec.Decompress(data); err != nil {
+		return err
+	}
+
+	return c.Unmarshal(data, v)
+}
+
+// EncryptField 加密字段值写入 smap, 设置了 blind 选项时同时写入盲索引字段
+// table 字段所在的表名, 与字段名一起作为附加认证数据
+func EncryptField(smap map[string]any, table string, tagName string, v any, options map[string]string) error {
+	cipher, blind, err := encryptValue(table, tagName, v, options)
+	if err != nil {
+		return err
+	}
+
+	smap[tagName] = cipher
+	if col := options["blind"]; col != "" {
+		smap[col] = blind
+	}
 	return nil
 }
 
+// encryptValue 加密字段值, 返回密文及盲索引 (没有 blind 选项时为 nil), NULL 不加密
+func encryptValue(table string, tagName string, v any, options map[string]string) (any, any, error) {
+	plain, err := PlainBytes(v, options)
+	if err != nil || plain == nil {
+		return nil, nil, err
+	}
+
+	cipher, err := codec.Encrypt(plain, codec.FieldAAD(table, tagName))
+	if err != nil {
+		return nil, nil, err
+	}
+	if options["blind"] == "" {
+		return cipher, nil, nil
+	}
+
+	blind, err := codec.BlindIndex(plain)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cipher, blind, nil
+}
+
+// tabler 实现了 TableName 的模型
+type tabler interface {
+	TableName() string
+}
+
+// TableNameOf 获取字段所在的表名, 即从 v 开始沿 index 路径上最外层实现了 TableName 的结构体的表名
+// 如联表查询的组合结构体中为各别名字段的模型, 都没有实现时返回空字符串
+func TableNameOf(v reflect.Value, index []int) string {
+	for i := 0; ; i++ {
+		v = reflect.Indirect(v)
+		if v.Kind() != reflect.Struct {
+			return ""
+		}
+
+		// 不可寻址时复制一份, 以支持指针接收者的 TableName
+		addr := v
+		if v.CanAddr() {
+			addr = v.Addr()
+		} else {
+			addr = reflect.New(v.Type())
+			addr.Elem().Set(v)
+		}
+		if t, ok := addr.Interface().(tabler); ok {
+			return t.TableName()
+		}
+
+		if i >= len(index)-1 {
+			return ""
+		}
+		if v = v.Field(index[i]); v.Kind() == reflect.Ptr && v.IsNil() {
+			return ""
+		}
+	}
+}
+
+// PlainBytes 加密字段以及盲索引使用的明文, 复杂类型按字段的编解码器序列化, 时间使用 RFC3339Nano 格式, NULL 返回 nil
+func PlainBytes(v any, options map[string]string) ([]byte, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		val, err := valuer.Value()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		v = val
+	}
+
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(val), nil
+	case []byte:
+		return val, nil
+	case time.Time:
+		return []byte(val.Format(time.RFC3339Nano)), nil
+	}
+
+	if IsComplexType(reflect.TypeOf(v)) {
+		data, err := EncodeComplex(v, options)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := data.(string); ok {
+			return []byte(s), nil
+		}
+		return data.([]byte), nil
+	}
+
+	return []byte(fmt.Sprint(v)), nil
+}
+
+// BlindColumns 获取结构体中加密字段的盲索引字段名 盲索引字段 => 加密字段
+func BlindColumns(typ reflect.Type, tag string) map[string]string {
+	return GetStructMeta(typ, tag).Blinds
+}
+
 // IsComplexType 判断是否为复杂数据结构
 func IsComplexType(typ reflect.Type) bool {
 	kind := typ.Kind()
@@ -111,6 +344,11 @@ func IsComplexType(typ reflect.Type) bool {
 		return false
 	}
 
+	// time.Time 以及 sql.NullTime, xorm.Null 等自行实现读写的类型交由驱动处理
+	if typ == timeType || typ.Implements(valuerType) || reflect.PtrTo(typ).Implements(scannerType) {
+		return false
+	}
+
 	return true
 }
 
@@ -128,3 +366,157 @@ func MapKeys[K constraints.Ordered, V any](m map[K]V, order bool) []K {
 	}
 	return keys
 }
+
+// OrderedKeys 获取Map中所有的Key, 在 order 中的按 order 的顺序排在前面, 其余按字典序排在后面
+// 用于按结构体字段声明顺序生成稳定的SQL语句
+func OrderedKeys[V any](m map[string]V, order []string) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(order))
+	for _, k := range order {
+		if _, ok := m[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	rest := len(keys)
+	for k := range m {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[rest:])
+
+	return keys
+}
+
+// SetFieldString 将字符串转化为字段类型后写入, 复杂类型按 JSON 解析
+func SetFieldString(f reflect.Value, s string) error {
+	typ := f.Type()
+
+	switch {
+	case typ == timeType:
+		t, err := ParseTime(s)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(t))
+		return nil
+	case reflect.PtrTo(typ).Implements(scannerType):
+		scanner := f.Addr().Interface().(sql.Scanner)
+		// 空字符串优先按字符串写入 (如 sql.NullString 的有效空字符串), 无法写入时 (如 sql.NullInt64) 视为 NULL
+		if s == "" {
+			if scanner.Scan(s) == nil {
+				return nil
+			}
+			return errors.WithStack(scanner.Scan(nil))
+		}
+		// 仅时间类型 (如 sql.NullTime) 先按时间解析, 其余直接写入字符串
+		if holdsTime(typ) {
+			if t, err := ParseTime(s); err == nil && scanner.Scan(t) == nil {
+				return nil
+			}
+		}
+		return errors.WithStack(scanner.Scan(s))
+	case IsComplexType(typ):
+		if s == "" {
+			f.Set(reflect.Zero(typ))
+			return nil
+		}
+		return errors.WithStack(sonic.UnmarshalString(s, f.Addr().Interface()))
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		f.SetFloat(fl)
+	case reflect.Slice:
+		// []byte
+		f.SetBytes([]byte(s))
+	default:
+		return errors.New(fmt.Sprintf("unsupported field type %s", typ))
+	}
+	return nil
+}
+
+// FieldString 字段值转化为字符串 (如写入 CSV), 与 SetFieldString 相反, 值为 NULL 时第二个返回值为 true
+func FieldString(v any) (string, bool, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		val, err := valuer.Value()
+		if err != nil {
+			return "", false, errors.WithStack(err)
+		}
+		v = val
+	}
+
+	switch val := v.(type) {
+	case nil:
+		return "", true, nil
+	case string:
+		return val, false, nil
+	case []byte:
+		if val == nil {
+			return "", true, nil
+		}
+		return string(val), false, nil
+	case bool:
+		if val {
+			return "1", false, nil
+		}
+		return "0", false, nil
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05.999999"), false, nil
+	case float32:
+		return strconv.FormatFloat(float64(val), 'g', -1, 32), false, nil
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64), false, nil
+	default:
+		return fmt.Sprint(val), false, nil
+	}
+}
+
+// holdsTime 是否为包含 time.Time 字段的结构体 如: sql.NullTime xorm.Null[time.Time]
+func holdsTime(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).Type == timeType {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTime 按 TimeLayouts 中的格式解析时间
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range TimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New(fmt.Sprintf("invalid time %q", s))
+}
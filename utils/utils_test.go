@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+func TestOrderedKeys(t *testing.T) {
+	m := map[string]int{"id": 1, "name": 2, "gold": 3, "zone": 4, "attrs": 5, "exp": 6}
+
+	cases := []struct {
+		name  string
+		order []string
+		want  []string
+	}{
+		{"nil order sorted", nil, []string{"attrs", "exp", "gold", "id", "name", "zone"}},
+		{"order first then sorted tail", []string{"name", "id", "gold"}, []string{"name", "id", "gold", "attrs", "exp", "zone"}},
+		{"order keys missing in map", []string{"level", "gold", "missing", "id"}, []string{"gold", "id", "attrs", "exp", "name", "zone"}},
+		{"duplicate order keys", []string{"zone", "id", "zone", "id"}, []string{"zone", "id", "attrs", "exp", "gold", "name"}},
+		{"order covers all", []string{"zone", "exp", "attrs", "name", "id", "gold"}, []string{"zone", "exp", "attrs", "name", "id", "gold"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				if got := OrderedKeys(m, c.order); !reflect.DeepEqual(got, c.want) {
+					t.Fatalf("OrderedKeys(%v) = %v, want %v", c.order, got, c.want)
+				}
+			}
+		})
+	}
+
+	if got := OrderedKeys(map[string]int{}, []string{"id"}); len(got) != 0 {
+		t.Errorf("OrderedKeys(empty) = %v, want empty", got)
+	}
+}
+
+type complexValuer struct{ V int }
+
+func (complexValuer) Value() (driver.Value, error) { return nil, nil }
+
+func TestIsComplexType(t *testing.T) {
+	cases := []struct {
+		v    any
+		want bool
+	}{
+		{struct{ A int }{}, true},
+		{map[string]int{}, true},
+		{[]string{}, true},
+		{[]byte{}, false},
+		{[16]byte{}, false},
+		{int64(0), false},
+		{"", false},
+		{time.Time{}, false},
+		{sql.NullString{}, false},
+		{sql.NullTime{}, false},
+		{types.JSONText{}, false},
+		{complexValuer{}, false},
+	}
+
+	for _, c := range cases {
+		if got := IsComplexType(reflect.TypeOf(c.v)); got != c.want {
+			t.Errorf("IsComplexType(%T) = %v, want %v", c.v, got, c.want)
+		}
+	}
+}
+
+func TestSetFieldStringEmpty(t *testing.T) {
+	var s sql.NullString
+	if err := SetFieldString(reflect.ValueOf(&s).Elem(), ""); err != nil || !s.Valid || s.String != "" {
+		t.Errorf("NullString = %+v, %v, want valid empty string", s, err)
+	}
+
+	i := sql.NullInt64{Int64: 1, Valid: true}
+	if err := SetFieldString(reflect.ValueOf(&i).Elem(), ""); err != nil || i.Valid {
+		t.Errorf("NullInt64 = %+v, %v, want NULL", i, err)
+	}
+
+	tm := sql.NullTime{Valid: true}
+	if err := SetFieldString(reflect.ValueOf(&tm).Elem(), ""); err != nil || tm.Valid {
+		t.Errorf("NullTime = %+v, %v, want NULL", tm, err)
+	}
+}
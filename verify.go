@@ -0,0 +1,209 @@
+package xorm
+
+import (
+	dbSql "database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Pius-x/xorm/utils"
+	"github.com/pkg/errors"
+)
+
+// SchemaIssue 结构体与表结构不一致的问题
+type SchemaIssue struct {
+	Table  string
+	Column string
+	Reason string
+}
+
+func (i SchemaIssue) String() string {
+	if i.Column == "" {
+		return fmt.Sprintf("%s: %s", i.Table, i.Reason)
+	}
+	return fmt.Sprintf("%s.%s: %s", i.Table, i.Column, i.Reason)
+}
+
+// VerifyError 校验不通过时返回的错误
+type VerifyError struct {
+	Issues []SchemaIssue
+}
+
+func (e *VerifyError) Error() string {
+	lines := make([]string, 0, len(e.Issues)+1)
+	lines = append(lines, fmt.Sprintf("schema verify failed with %d issue(s):", len(e.Issues)))
+	for _, issue := range e.Issues {
+		lines = append(lines, utils.Concat("\t", issue.String()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 表字段信息
+type columnSchema struct {
+	Name     string           `db:"name"`
+	DataType string           `db:"data_type"`
+	Nullable string           `db:"nullable"`
+	Default  dbSql.NullString `db:"dft"`
+	Extra    string           `db:"extra"`
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	stringType = reflect.TypeOf("")
+)
+
+// 各类 Go 类型可以对应的数据库类型
+var (
+	intDataTypes     = []string{"tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year", "bit"}
+	floatDataTypes   = []string{"float", "double", "real", "decimal", "numeric"}
+	timeDataTypes    = []string{"date", "datetime", "timestamp"}
+	complexDataTypes = []string{"char", "varchar", "tinytext", "text", "mediumtext", "longtext", "json",
+		"binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob"}
+	// 加密字段写入的是二进制密文
+	encryptedDataTypes = []string{"binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob"}
+)
+
+// Verify 校验结构体与数据库中的表结构是否一致, 用于服务启动时快速失败
+// 检查项: 结构体字段在表中不存在; 表中存在结构体未包含且没有默认值的 NOT NULL 字段; 字段类型不兼容
+// 加密字段需为二进制字段, 盲索引字段视为结构体中的字符串字段
+// 校验不通过时返回 *VerifyError
+func (cli *Cli) Verify(models ...SqlxTabler) error {
+	var issues []SchemaIssue
+
+	for _, model := range models {
+		tb := model.TableName()
+
+		fieldTypes, err := cli.modelFieldTypes(model)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("解析结构体 %T 出错", model))
+		}
+
+		meta := utils.GetStructMeta(reflect.TypeOf(model), Tag)
+		for blind := range meta.Blinds {
+			fieldTypes[blind] = stringType
+		}
+
+		columns, err := cli.tableColumns(tb)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("查询表 %s 结构出错", tb))
+		}
+
+		if len(columns) == 0 {
+			issues = append(issues, SchemaIssue{Table: tb, Reason: "table not found"})
+			continue
+		}
+
+		for _, name := range utils.MapKeys(fieldTypes, true) {
+			col, ok := columns[name]
+			if !ok {
+				issues = append(issues, SchemaIssue{Table: tb, Column: name, Reason: "column not found in table"})
+				continue
+			}
+
+			if _, ok = meta.Encrypted[name]; ok {
+				if !utils.InSlice(col.DataType, encryptedDataTypes) {
+					issues = append(issues, SchemaIssue{Table: tb, Column: name,
+						Reason: fmt.Sprintf("encrypted field requires binary column, got %s", col.DataType)})
+				}
+				continue
+			}
+
+			if typ := fieldTypes[name]; typ != nil && !isCompatibleType(typ, col.DataType) {
+				issues = append(issues, SchemaIssue{Table: tb, Column: name,
+					Reason: fmt.Sprintf("field type %s is incompatible with column type %s", typ, col.DataType)})
+			}
+		}
+
+		for _, name := range utils.MapKeys(columns, true) {
+			col := columns[name]
+			if _, ok := fieldTypes[name]; ok {
+				continue
+			}
+			if col.Nullable == "NO" && !col.Default.Valid && !strings.Contains(col.Extra, "auto_increment") &&
+				!strings.Contains(col.Extra, "GENERATED") {
+				issues = append(issues, SchemaIssue{Table: tb, Column: name,
+					Reason: "NOT NULL column without default is missing in struct"})
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		return &VerifyError{Issues: issues}
+	}
+	return nil
+}
+
+// modelFieldTypes 获取结构体中带 db 标签字段的类型
+func (cli *Cli) modelFieldTypes(model SqlxTabler) (map[string]reflect.Type, error) {
+	val := reflect.Indirect(reflect.ValueOf(model))
+	if !val.IsValid() {
+		val = reflect.New(reflect.TypeOf(model).Elem()).Elem()
+	}
+
+	smap := make(map[string]any)
+	if err := utils.ReflectToMap(smap, val, Tag, false); err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]reflect.Type, len(smap))
+	for name, v := range smap {
+		types[name] = reflect.TypeOf(v)
+	}
+	return types, nil
+}
+
+// tableColumns 从 information_schema 中查询表字段
+func (cli *Cli) tableColumns(tb string) (map[string]columnSchema, error) {
+	schema := "DATABASE()"
+	var args []any
+	if i := strings.LastIndex(tb, "."); i >= 0 {
+		schema = "?"
+		args = append(args, strings.Trim(tb[:i], "`"))
+		tb = tb[i+1:]
+	}
+	args = append(args, strings.Trim(tb, "`"))
+
+	query := utils.Concat("SELECT COLUMN_NAME AS name, DATA_TYPE AS data_type, IS_NULLABLE AS nullable, ",
+		"COLUMN_DEFAULT AS dft, EXTRA AS extra FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ", schema,
+		" AND TABLE_NAME = ?")
+
+	var rows []columnSchema
+	if err := cli.Select(&rows, query, args...); err != nil && !errors.Is(err, dbSql.ErrNoRows) {
+		return nil, err
+	}
+
+	columns := make(map[string]columnSchema, len(rows))
+	for _, row := range rows {
+		row.DataType = strings.ToLower(row.DataType)
+		columns[row.Name] = row
+	}
+	return columns, nil
+}
+
+// isCompatibleType 判断结构体字段类型能否读写对应的数据库字段类型
+func isCompatibleType(typ reflect.Type, dataType string) bool {
+	if typ == timeType {
+		return utils.InSlice(dataType, timeDataTypes)
+	}
+
+	// 自行实现读写的类型 (sql.NullString, types.JSONText 等) 不做判断
+	if !utils.IsComplexType(typ) && typ.Kind() == reflect.Struct {
+		return true
+	}
+
+	if utils.IsComplexType(typ) {
+		return utils.InSlice(dataType, complexDataTypes)
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return utils.InSlice(dataType, intDataTypes)
+	case reflect.Float32, reflect.Float64:
+		return utils.InSlice(dataType, intDataTypes) || utils.InSlice(dataType, floatDataTypes)
+	default:
+		// string []byte 可以接收任意类型
+		return true
+	}
+}
@@ -0,0 +1,51 @@
+package xorm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type verifyUser struct {
+	ID    int64  `db:"id"`
+	Phone string `db:"phone,encrypt,blind=phone_bidx"`
+	Level int64  `db:"level,encrypt"`
+}
+
+func (verifyUser) TableName() string { return "user" }
+
+// verifyDB 返回 information_schema 中的字段 名称 类型 是否可为 NULL
+func verifyDB(columns ...[3]string) *stubDB {
+	return &stubDB{query: func(string, []driver.Value) ([]string, [][]driver.Value, error) {
+		rows := make([][]driver.Value, 0, len(columns))
+		for _, c := range columns {
+			rows = append(rows, []driver.Value{c[0], c[1], c[2], nil, ""})
+		}
+		return []string{"name", "data_type", "nullable", "dft", "extra"}, rows, nil
+	}}
+}
+
+func TestVerifyEncrypted(t *testing.T) {
+	db := verifyDB([3]string{"id", "bigint", "NO"}, [3]string{"phone", "varbinary", "NO"},
+		[3]string{"phone_bidx", "char", "NO"}, [3]string{"level", "blob", "YES"})
+	if err := stubCli(db).Verify(&verifyUser{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db = verifyDB([3]string{"id", "bigint", "NO"}, [3]string{"phone", "varbinary", "NO"},
+		[3]string{"level", "bigint", "YES"})
+	err := stubCli(db).Verify(&verifyUser{})
+
+	var verifyErr *VerifyError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("err = %v, want *VerifyError", err)
+	}
+	want := []SchemaIssue{
+		{Table: "user", Column: "level", Reason: "encrypted field requires binary column, got bigint"},
+		{Table: "user", Column: "phone_bidx", Reason: "column not found in table"},
+	}
+	if !reflect.DeepEqual(verifyErr.Issues, want) {
+		t.Errorf("issues = %+v, want %+v", verifyErr.Issues, want)
+	}
+}
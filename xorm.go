@@ -6,8 +6,8 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/Pius-x/xorm/sqlx_inherit"
 	"github.com/Pius-x/xorm/utils"
-	"github.com/bytedance/sonic"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 )
@@ -16,11 +16,56 @@ const Tag = "db"
 
 type Cli struct {
 	*sqlx.DB
+
+	preloads []preload // Preload 设置的关联预加载
+	cols     []string  // Cols 设置的更新字段
+	omits    []string  // Omit 设置的忽略字段
+	nonZero  bool      // NonZeroOnly 只更新非零值字段
+
+	hints map[string]TypeHint // Hints 设置的 Map 查询字段解码类型
+	keyBy string              // KeyBy 设置的 Map 结果的键字段
+
+	lenient   bool               // Lenient 忽略结构体中不存在的列
+	onUnknown UnknownColumnsFunc // Lenient 设置的被忽略列的回调
+}
+
+// UnknownColumnsFunc 报告查询结果中结构体没有对应字段的列
+type UnknownColumnsFunc func(dest reflect.Type, columns []string)
+
+// session 复制一份 Cli 用于设置链式调用的选项, 不影响原 Cli
+func (cli *Cli) session() *Cli {
+	s := *cli
+	return &s
+}
+
+// Lenient 宽松模式, 查询结果中结构体没有对应字段的列会被忽略而不是返回错误 (如滚动发布时先加了字段)
+// report 可选, 每次查询有被忽略的列时回调, 可用于打日志或上报指标
+// 需要整个 Cli 都使用宽松模式时保存返回值即可 如: cli = cli.Lenient(report)
+func (cli *Cli) Lenient(report ...UnknownColumnsFunc) *Cli {
+	s := cli.session()
+	s.lenient = true
+	if len(report) > 0 {
+		s.onUnknown = report[0]
+	}
+	return s
+}
+
+// newRows 包装查询结果, 带上 Mapper 以及宽松模式设置
+func (cli *Cli) newRows(r *dbSql.Rows) *sqlx_inherit.Rows {
+	return &sqlx_inherit.Rows{Rows: r, Mapper: cli.Mapper, Lenient: cli.lenient, OnUnknown: cli.onUnknown}
+}
+
+// sqlxDB 宽松模式下使用 sqlx 的 Unsafe 模式 (不报告被忽略的列)
+func (cli *Cli) sqlxDB() *sqlx.DB {
+	if cli.lenient {
+		return cli.DB.Unsafe()
+	}
+	return cli.DB
 }
 
 // Get 查询单行数据
 func (cli *Cli) Get(dest any, query string, args ...any) error {
-	err := cli.DB.Get(dest, query, args...)
+	err := cli.sqlxDB().Get(dest, query, args...)
 	if err != nil && !errors.Is(err, dbSql.ErrNoRows) {
 		return errors.WithStack(err)
 
@@ -30,7 +75,7 @@ func (cli *Cli) Get(dest any, query string, args ...any) error {
 
 // Select 查询多行数据
 func (cli *Cli) Select(dest any, query string, args ...any) error {
-	err := cli.DB.Select(dest, query, args...)
+	err := cli.sqlxDB().Select(dest, query, args...)
 	if err != nil && !errors.Is(err, dbSql.ErrNoRows) {
 		return errors.WithStack(err)
 	}
@@ -103,12 +148,94 @@ func (cli *Cli) Count(dest any, tb string, where string, args ...any) (err error
 	return cli.Get(dest, query, args...)
 }
 
+// Sum 求和 (没有记录时为 0)
+// dest 接收结果的变量指针
+// tb 数据库表名
+// field 字段名
+// where 条件语句 如: "WHERE id = 1" 或者 "WHERE id = ?" 参数放在args中
+func (cli *Cli) Sum(dest any, tb string, field string, where string, args ...any) error {
+	return cli.aggregate(dest, "SUM", tb, field, where, args)
+}
+
+// Avg 求平均值 (没有记录时为 NULL, dest 可使用 sql.NullFloat64)
+func (cli *Cli) Avg(dest any, tb string, field string, where string, args ...any) error {
+	return cli.aggregate(dest, "AVG", tb, field, where, args)
+}
+
+// Min 求最小值 (没有记录时为 NULL, dest 可使用 sql.NullXXX)
+func (cli *Cli) Min(dest any, tb string, field string, where string, args ...any) error {
+	return cli.aggregate(dest, "MIN", tb, field, where, args)
+}
+
+// Max 求最大值 (没有记录时为 NULL, dest 可使用 sql.NullXXX)
+func (cli *Cli) Max(dest any, tb string, field string, where string, args ...any) error {
+	return cli.aggregate(dest, "MAX", tb, field, where, args)
+}
+
+// GroupCount 分组计数
+// dest Map指针 如: *map[string]int64 键为分组字段的值
+// tb 数据库表名
+// field 分组字段名
+// where 条件语句 如: "WHERE id = 1" 或者 "WHERE id = ?" 参数放在args中
+func (cli *Cli) GroupCount(dest any, tb string, field string, where string, args ...any) error {
+	return cli.GroupAggregate(dest, tb, []string{field}, []string{"COUNT(1)"}, where, args...)
+}
+
+// GroupAggregate 分组聚合查询
+// dest 单个分组字段及单个聚合表达式时可为Map指针 如: *map[string]float64;
+// 否则为结构体切片指针或Map切片指针, 聚合表达式需使用 AS 指定与 db 标签对应的别名
+// tb 数据库表名
+// groupBy 分组字段名 如: []string{"day", "channel"}
+// aggregates 聚合表达式 如: []string{"SUM(amount) AS revenue", "COUNT(1) AS orders"}
+// where 条件语句 如: "WHERE id = 1" 或者 "WHERE id = ?" 参数放在args中
+func (cli *Cli) GroupAggregate(dest any, tb string, groupBy []string, aggregates []string, where string, args ...any) error {
+
+	var err error
+	where, args, err = sqlx.In(where, args...)
+	if err != nil {
+		return errors.Wrap(err, "参数解析失败")
+	}
+
+	query, err := cli.buildGroupQuery(tb, groupBy, aggregates, where)
+	if err != nil {
+		return errors.WithMessage(err, "构建分组查询语句出错")
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(dest)).Kind() {
+	case reflect.Map:
+		if len(groupBy) != 1 || len(aggregates) != 1 {
+			return errors.New("map dest expect one group field and one aggregate")
+		}
+		err = cli.groupMapSearch(dest, query, args...)
+	case reflect.Slice:
+		if reflect.Indirect(reflect.ValueOf(dest)).Type().Elem().Kind() == reflect.Map {
+			err = cli.mapSearch(dest, query, args...)
+		} else {
+			err = cli.search(dest, query, args...)
+		}
+	default:
+		return errors.New(fmt.Sprintf("unexpected dest type %T", dest))
+	}
+
+	if err != nil && !errors.Is(err, dbSql.ErrNoRows) {
+		return errors.WithMessage(err, fmt.Sprintf("语句执行出错, sql:%s", query))
+	}
+
+	return nil
+}
+
 // Search 查询 (支持嵌套查询,嵌套结构体,切片,数组,Map)
-// dest 若是结构体指针 则为单行查询; 若是结构体切片指针,则为多行查询
+// 可配合 Preload 预加载关联字段 如: cli.Preload("Items").Search(&players, "WHERE level > ?", 10)
+// dest 若是结构体指针 则为单行查询; 若是结构体切片指针,则为多行查询;
+// 若是 *map[K]T 或 *map[K][]T, 则以主键 (或 KeyBy 指定的字段) 为键写入 Map, 后者按键分组
 // where 条件语句 如: "WHERE id = 1" 或者 "WHERE id = ?" 参数放在args中
 // args 条件语句使用占位符?时的可变参数
 func (cli *Cli) Search(dest any, where string, args ...any) error {
 
+	if err := cli.checkPreloads(); err != nil {
+		return errors.WithMessage(err, "预加载参数错误")
+	}
+
 	tb, tags, err := cli.toTbAndTags(dest)
 	if err != nil {
 		return errors.WithMessage(err, "获取结构体表名和Tags出错")
@@ -119,18 +246,51 @@ func (cli *Cli) Search(dest any, where string, args ...any) error {
 		return errors.Wrap(err, "参数解析失败")
 	}
 
-	query, err := cli.buildSearchQuery(tb, tags, where)
+	query, err := cachedQuery(reflect.TypeOf(dest), tb, "SELECT", func() (string, error) {
+		return cli.buildSearchQuery(tb, tags, "")
+	})
 	if err != nil {
 		return errors.WithMessage(err, "构建查询语句出错")
 	}
+	query += where
 
 	// 过滤没有记录的正常情况
-	err = cli.search(dest, query, args...)
+	if cli.isKeyedDest(dest) {
+		err = cli.keyedSearch(dest, query, args...)
+	} else {
+		err = cli.search(dest, query, args...)
+	}
 	if err != nil && !errors.Is(err, dbSql.ErrNoRows) {
 		return errors.WithMessage(err, fmt.Sprintf("语句执行出错, sql:%s", query))
 	}
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	// map[K]T 的值不可寻址, 需要预加载或保存快照时复制后处理, 处理完写回
+	target := dest
+	if elem := keyedElemType(dest); elem != nil && (len(cli.preloads) > 0 || reflect.PtrTo(elem).Implements(trackerType)) {
+		var writeBack func()
+		target, writeBack = addressable(dest)
+		defer writeBack()
+	}
+
+	if len(cli.preloads) > 0 {
+		if err = cli.loadRelations(target); err != nil {
+			return errors.WithMessage(err, "预加载关联出错")
+		}
+	}
+
+	return cli.takeSnapshots(target)
+}
+
+// KeyBy 指定 Search 查询到 Map 时作为键的字段, 默认为主键
+// 如: cli.KeyBy("guild_id").Search(&members, "") members 为 map[int64][]Member
+func (cli *Cli) KeyBy(col string) *Cli {
+	s := cli.session()
+	s.keyBy = col
+	return s
 }
 
 // SearchOneField 查询单个字段
@@ -147,7 +307,7 @@ func (cli *Cli) SearchOneField(dest any, tb string, field string, where string,
 			return err
 		}
 
-		if err := sonic.UnmarshalString(tmpDest, dest); err != nil {
+		if err := utils.DecodeComplex([]byte(tmpDest), dest, nil); err != nil {
 			return errors.WithStack(err)
 		}
 		return nil
@@ -197,6 +357,29 @@ func (cli *Cli) SearchFields(dest any, tb string, fields []string, where string,
 	return err
 }
 
+// TypeHint Map 查询结果中字段值的解码类型
+type TypeHint = sqlx_inherit.TypeHint
+
+const (
+	HintAuto   = sqlx_inherit.HintAuto   // 根据 ColumnTypes 推断
+	HintInt    = sqlx_inherit.HintInt    // int64
+	HintUint   = sqlx_inherit.HintUint   // uint64
+	HintFloat  = sqlx_inherit.HintFloat  // float64
+	HintString = sqlx_inherit.HintString // string
+	HintBool   = sqlx_inherit.HintBool   // bool
+	HintTime   = sqlx_inherit.HintTime   // time.Time
+	HintJSON   = sqlx_inherit.HintJSON   // JSON 解析为 map[string]any []any 等
+	HintBytes  = sqlx_inherit.HintBytes  // []byte
+)
+
+// Hints 指定 Map 查询 (SearchFields 等) 中字段值的解码类型, 未指定的字段根据数据库字段类型推断
+// 如: cli.Hints(map[string]xorm.TypeHint{"flag": xorm.HintBool}).SearchFields(&rows, "user", []string{"id", "flag"}, "")
+func (cli *Cli) Hints(hints map[string]TypeHint) *Cli {
+	s := cli.session()
+	s.hints = hints
+	return s
+}
+
 // endregion
 
 // region Key 增
@@ -213,12 +396,86 @@ func (cli *Cli) Insert(record any) (dbSql.Result, error) {
 	return cli.insert(records)
 }
 
+// InsertStat 插入结果统计
+type InsertStat struct {
+	Total    int64 // 提交的记录数
+	Inserted int64 // 新插入的记录数
+	Ignored  int64 // 因唯一键冲突被忽略的记录数 (InsertIgnore)
+	Replaced int64 // 删除旧记录后重新插入的记录数 (Replace)
+}
+
+// InsertIgnore 插入, 唯一键冲突的记录被忽略 (INSERT IGNORE), 适用于幂等写入
+// record 输入结构体或结构体切片
+func (cli *Cli) InsertIgnore(record any) (InsertStat, error) {
+	records, err := cli.toSqlxTablers(record)
+	if err != nil {
+		return InsertStat{}, err
+	}
+
+	result, err := cli.insertWith(insertIgnoreVerb, records)
+	if err != nil {
+		return InsertStat{}, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return InsertStat{}, errors.WithStack(err)
+	}
+
+	total := int64(len(records))
+	return InsertStat{Total: total, Inserted: affected, Ignored: total - affected}, nil
+}
+
+// Replace 插入, 唯一键冲突时删除旧记录后重新插入 (REPLACE INTO)
+// record 输入结构体或结构体切片
+func (cli *Cli) Replace(record any) (InsertStat, error) {
+	records, err := cli.toSqlxTablers(record)
+	if err != nil {
+		return InsertStat{}, err
+	}
+
+	result, err := cli.insertWith(replaceVerb, records)
+	if err != nil {
+		return InsertStat{}, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return InsertStat{}, errors.WithStack(err)
+	}
+
+	// 替换的记录影响行数为2 (删除+插入)
+	total := int64(len(records))
+	replaced := max(min(affected-total, total), 0)
+	return InsertStat{Total: total, Inserted: total - replaced, Replaced: replaced}, nil
+}
+
+// InsertSelect 将查询结果插入到目标表 (INSERT INTO ... SELECT), 适用于归档
+// tb 目标表名
+// cols 目标表字段, 为空时按目标表字段顺序插入
+// srcQuery 查询语句 如: "SELECT id, uid FROM orders WHERE created_at < ?" 参数放在args中
+func (cli *Cli) InsertSelect(tb string, cols []string, srcQuery string, args ...any) (dbSql.Result, error) {
+
+	srcQuery, args, err := sqlx.In(srcQuery, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "参数解析失败")
+	}
+
+	query := cli.buildInsertSelectQuery(tb, cols, srcQuery)
+	result, err := cli.Exec(query, args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "InsertSelect 语句执行出错")
+	}
+	return result, nil
+}
+
 // endregion
 
 // region Key 改
 
 // UpdateByStruct 结构体更新
 // record 输入实现 SqlxTabler 接口的结构体 (需要填充所有的结构体字段,不填写默认为零值)
+// 可配合 Cols Omit NonZeroOnly 只更新部分字段 如: cli.Cols("name", "age").UpdateByStruct(user, "id")
 // fields 需要判断的字段
 func (cli *Cli) UpdateByStruct(record any, fields ...string) (dbSql.Result, error) {
 	if len(fields) == 0 {
@@ -235,6 +492,7 @@ func (cli *Cli) UpdateByStruct(record any, fields ...string) (dbSql.Result, erro
 	}
 
 	tb := records[0].TableName()
+	order := utils.GetStructMeta(reflect.TypeOf(records[0]), Tag).WriteColumns
 
 	var query string
 	var args []any
@@ -245,7 +503,11 @@ func (cli *Cli) UpdateByStruct(record any, fields ...string) (dbSql.Result, erro
 			return nil, errors.WithMessage(err, "转化成Map切片出错")
 		}
 
-		query, args, err = cli.buildUpdateQuery(tb, updateMap, fields)
+		if updateMap, err = cli.filterUpdateMap(records[0], updateMap, fields); err != nil {
+			return nil, err
+		}
+
+		query, args, err = cli.buildUpdateQuery(tb, updateMap, order, fields)
 		if err != nil {
 			return nil, errors.WithMessage(err, "构建更新语句出错")
 		}
@@ -255,7 +517,13 @@ func (cli *Cli) UpdateByStruct(record any, fields ...string) (dbSql.Result, erro
 			return nil, err
 		}
 
-		query, args, err = cli.buildUpdateBatchQuery(tb, mapSlice, fields...)
+		for i := range mapSlice {
+			if mapSlice[i], err = cli.filterUpdateMap(records[i], mapSlice[i], fields); err != nil {
+				return nil, err
+			}
+		}
+
+		query, args, err = cli.buildUpdateBatchQuery(tb, mapSlice, order, fields...)
 		if err != nil {
 			return nil, errors.WithMessage(err, "构建更新语句出错")
 		}
@@ -269,9 +537,31 @@ func (cli *Cli) UpdateByStruct(record any, fields ...string) (dbSql.Result, erro
 	return result, nil
 }
 
+// Cols 只更新指定的字段 (对 UpdateByStruct 生效)
+func (cli *Cli) Cols(cols ...string) *Cli {
+	s := cli.session()
+	s.cols = append(append([]string(nil), cli.cols...), cols...)
+	return s
+}
+
+// Omit 不更新指定的字段 (对 UpdateByStruct 生效)
+func (cli *Cli) Omit(cols ...string) *Cli {
+	s := cli.session()
+	s.omits = append(append([]string(nil), cli.omits...), cols...)
+	return s
+}
+
+// NonZeroOnly 只更新非零值的字段, 指针字段不为 nil 即视为需要更新 (对 UpdateByStruct 生效)
+func (cli *Cli) NonZeroOnly() *Cli {
+	s := cli.session()
+	s.nonZero = true
+	return s
+}
+
 // UpdateByMap Map更新
 // tb 数据库表名
-// record 输入需要更新的字段的Map
+// record 输入需要更新的字段的Map (值可以为 xorm.Expr 表达式)
+// 表对应的模型有加密字段时, 需先通过 RegisterModels 登记, 加密字段才会加密并同时更新盲索引字段
 // fields 需要判断的字段
 func (cli *Cli) UpdateByMap(tb string, record any, fields ...string) (dbSql.Result, error) {
 	if len(fields) == 0 {
@@ -282,22 +572,35 @@ func (cli *Cli) UpdateByMap(tb string, record any, fields ...string) (dbSql.Resu
 	var args []any
 	switch data := record.(type) {
 	case map[string]any:
+		data, err := encryptMap(tb, data, fields)
+		if err != nil {
+			return nil, errors.WithMessage(err, "加密字段出错")
+		}
+
 		updateMap, err := cli.stringifyMap(data)
 		if err != nil {
 			return nil, errors.WithMessage(err, "序列化UpdateMap出错")
 		}
 
-		query, args, err = cli.buildUpdateQuery(tb, updateMap, fields)
+		query, args, err = cli.buildUpdateQuery(tb, updateMap, nil, fields)
 		if err != nil {
 			return nil, errors.WithMessage(err, "构建单行更新语句出错")
 		}
 	case []map[string]any:
-		mapSlice, err := cli.stringifyMapSlice(data)
+		encrypted := make([]map[string]any, len(data))
+		for i, m := range data {
+			var err error
+			if encrypted[i], err = encryptMap(tb, m, fields); err != nil {
+				return nil, errors.WithMessage(err, "加密字段出错")
+			}
+		}
+
+		mapSlice, err := cli.stringifyMapSlice(encrypted)
 		if err != nil {
 			return nil, errors.WithMessage(err, "序列化UpdateMap切片出错")
 		}
 
-		query, args, err = cli.buildUpdateBatchQuery(tb, mapSlice, fields...)
+		query, args, err = cli.buildUpdateBatchQuery(tb, mapSlice, nil, fields...)
 		if err != nil {
 			return nil, errors.WithMessage(err, "构建多行更新语句出错")
 		}
@@ -313,6 +616,23 @@ func (cli *Cli) UpdateByMap(tb string, record any, fields ...string) (dbSql.Resu
 	return result, nil
 }
 
+// Incr 原子增加 如: cli.Incr("player", map[string]any{"gold": 100}, "WHERE id = ?", 1)
+// tb 数据库表名
+// incr 字段名 -> 增加的值
+// where 条件语句 如: "WHERE id = ?" 参数放在args中
+func (cli *Cli) Incr(tb string, incr map[string]any, where string, args ...any) (dbSql.Result, error) {
+	return cli.incr(tb, incr, "+", false, where, args)
+}
+
+// Decr 原子减少
+// tb 数据库表名
+// decr 字段名 -> 减少的值
+// floor 为 true 时附加 `col` >= ? 条件, 余额不足的记录不会被更新 (可通过 RowsAffected 判断)
+// where 条件语句 如: "WHERE id = ?" 参数放在args中 (不能包含 ORDER BY LIMIT)
+func (cli *Cli) Decr(tb string, decr map[string]any, floor bool, where string, args ...any) (dbSql.Result, error) {
+	return cli.incr(tb, decr, "-", floor, where, args)
+}
+
 // endregion
 
 // region Key 删
@@ -341,8 +661,9 @@ func (cli *Cli) Delete(tb string, where string, args ...any) (dbSql.Result, erro
 
 // Upsert 插入或更新 (不存在则插入,存在则更新) (支持嵌套插入,嵌套结构体,切片,数组,Map 会转换成字符串插入)
 // record 批量插入时 输入结构体切片; 单条插入时 输入结构体或结构体指针
+// opts 冲突时的更新方式 如: xorm.ExcludeCols("created_at"), xorm.UpdateIncr("count"), xorm.RowAlias("new")
 // 批量插入时 Result.LastInsertId 为第一条插入的自增ID或最后条记录插入的Id
-func (cli *Cli) Upsert(record any) (dbSql.Result, error) {
+func (cli *Cli) Upsert(record any, opts ...UpsertOption) (dbSql.Result, error) {
 	records, err := cli.toSqlxTablers(record)
 	if err != nil {
 		return nil, err
@@ -352,7 +673,7 @@ func (cli *Cli) Upsert(record any) (dbSql.Result, error) {
 		return nil, nil
 	}
 
-	return cli.upsert(records)
+	return cli.upsert(records, opts)
 }
 
 // endregion